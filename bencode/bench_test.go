@@ -0,0 +1,118 @@
+package bencode
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// benchTorrent 构造一个包含 pieces 个分片、100 个文件的种子
+func benchTorrent(pieces int) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("d8:announce30:http://tracker.example.com/ann4:infod5:filesl")
+	for i := 0; i < 100; i++ {
+		name := "file" + strconv.Itoa(i)
+		buf.WriteString("d6:lengthi" + strconv.Itoa(1<<20+i) + "e4:pathl3:dir" + strconv.Itoa(len(name)) + ":" + name + "ee")
+	}
+	buf.WriteString("e4:name5:bench12:piece lengthi262144e6:pieces")
+	buf.WriteString(strconv.Itoa(pieces*20) + ":")
+	buf.Write(bytes.Repeat([]byte{0xab}, pieces*20))
+	buf.WriteString("ee")
+	return buf.Bytes()
+}
+
+type benchInfo struct {
+	Files []struct {
+		Length int64    `bencode:"length"`
+		Path   []string `bencode:"path"`
+	} `bencode:"files"`
+	Name        string `bencode:"name"`
+	PieceLength int64  `bencode:"piece length"`
+	Pieces      []byte `bencode:"pieces"`
+}
+
+type benchTorrentFile struct {
+	Announce string     `bencode:"announce"`
+	Info     benchInfo  `bencode:"info"`
+	InfoRaw  RawMessage `bencode:"info"`
+}
+
+// benchKRPC 一个 DHT get_peers 响应
+var benchKRPC = []byte("d1:rd2:id20:abcdefghij01234567895:nodes52:" + string(bytes.Repeat([]byte{1}, 52)) + "5:token8:aoeusnth6:valuesl6:axje.u6:idhtnmee1:t2:aa1:y1:re")
+
+type benchKRPCMsg struct {
+	R struct {
+		ID     []byte   `bencode:"id"`
+		Nodes  []byte   `bencode:"nodes"`
+		Token  []byte   `bencode:"token"`
+		Values [][]byte `bencode:"values"`
+	} `bencode:"r"`
+	T string `bencode:"t"`
+	Y string `bencode:"y"`
+}
+
+func BenchmarkUnmarshalTorrent(b *testing.B) {
+	data := benchTorrent(100000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v benchTorrentFile
+		if err := Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalKRPC(b *testing.B) {
+	b.SetBytes(int64(len(benchKRPC)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v benchKRPCMsg
+		if err := Unmarshal(benchKRPC, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalAny(b *testing.B) {
+	b.SetBytes(int64(len(benchKRPC)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v any
+		if err := Unmarshal(benchKRPC, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	data := benchTorrent(100000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewScanner(data)
+		for {
+			_, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMarshalKRPC(b *testing.B) {
+	var v benchKRPCMsg
+	if err := Unmarshal(benchKRPC, &v); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
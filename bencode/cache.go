@@ -0,0 +1,190 @@
+package bencode
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// field 结构体中对应一个 bencode key 的字段
+type field struct {
+	name      string // 字段名，用于错误信息
+	key       string
+	index     []int // 字段的下标，嵌入结构体中的字段包含多级下标
+	depth     int   // 嵌套层级，直接声明的字段为 0
+	typ       reflect.Type
+	omitEmpty bool
+	required  bool
+}
+
+// structPlan 结构体类型的字段信息，每个类型只计算一次
+type structPlan struct {
+	fields   []field          // 按照声明顺序排列
+	keys     []string         // 去重后按原始字节升序排列的 key
+	byKey    map[string][]int // key 对应的字段在 fields 中的下标，按照声明顺序排列
+	required []int            // 带有 required 选项的字段在 fields 中的下标
+}
+
+var (
+	fieldCache   sync.Map // map[reflect.Type]*structPlan
+	encoderCache sync.Map // map[reflect.Type]encoderFunc
+	decoderCache sync.Map // map[reflect.Type]decoderFunc
+)
+
+// cachedFields 返回结构体类型 t 的字段信息
+func cachedFields(t reflect.Type) *structPlan {
+	if p, ok := fieldCache.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return p.(*structPlan)
+}
+
+// typeFields 解析结构体类型 t 的字段，忽略未导出的字段和标签为 - 的字段
+// 与 encoding/json 相同，没有标签名的嵌入结构体（或结构体指针）的字段会被提升到外层，
+// 同一个 key 出现在不同的嵌套层级时只保留层级最浅的字段
+func typeFields(t reflect.Type) *structPlan {
+	var fields []field
+	depths := map[string]int{}
+	visiting := map[reflect.Type]bool{}
+
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag, opts := parseTag(sf.Tag.Get("bencode"))
+			if tag == "-" {
+				continue
+			}
+			idx := append(append([]int{}, index...), i)
+
+			if sf.Anonymous && tag == "" {
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					// 解码时无法初始化未导出的嵌入指针
+					if !sf.IsExported() {
+						continue
+					}
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					if !visiting[ft] {
+						walk(ft, idx, depth+1)
+					}
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if tag == "" {
+				tag = sf.Name
+			}
+
+			if d, ok := depths[tag]; !ok || depth < d {
+				depths[tag] = depth
+			}
+			fields = append(fields, field{
+				name:      sf.Name,
+				key:       tag,
+				index:     idx,
+				depth:     depth,
+				typ:       sf.Type,
+				omitEmpty: opts.Contains("omitempty"),
+				required:  opts.Contains("required"),
+			})
+		}
+	}
+	walk(t, nil, 0)
+
+	p := &structPlan{byKey: map[string][]int{}}
+	for _, f := range fields {
+		if f.depth != depths[f.key] {
+			continue
+		}
+		if _, ok := p.byKey[f.key]; !ok {
+			p.keys = append(p.keys, f.key)
+		}
+		p.byKey[f.key] = append(p.byKey[f.key], len(p.fields))
+		if f.required {
+			p.required = append(p.required, len(p.fields))
+		}
+		p.fields = append(p.fields, f)
+	}
+
+	sort.Strings(p.keys)
+	return p
+}
+
+// fieldByIndex 和 reflect.Value.FieldByIndex 相同，但遇到 nil 的嵌入指针时，
+// alloc 为 true 则初始化该指针，否则返回 false
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// typeEncoder 返回类型 t 的编码函数
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// 递归类型在构造编码函数时会再次访问 t，先保存一个等待构造完成的函数
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := encoderCache.LoadOrStore(t, encoderFunc(func(buf *bytes.Buffer, v reflect.Value) error {
+		wg.Wait()
+		return f(buf, v)
+	}))
+	if loaded {
+		return fi.(encoderFunc)
+	}
+
+	f = newTypeEncoder(t)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+// typeDecoder 返回类型 t 的解码函数
+func typeDecoder(t reflect.Type) decoderFunc {
+	if f, ok := decoderCache.Load(t); ok {
+		return f.(decoderFunc)
+	}
+
+	var (
+		wg sync.WaitGroup
+		f  decoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := decoderCache.LoadOrStore(t, decoderFunc(func(d *decodeState, tok Token, v reflect.Value) error {
+		wg.Wait()
+		return f(d, tok, v)
+	}))
+	if loaded {
+		return fi.(decoderFunc)
+	}
+
+	f = newTypeDecoder(t)
+	wg.Done()
+	decoderCache.Store(t, f)
+	return f
+}
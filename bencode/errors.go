@@ -0,0 +1,64 @@
+package bencode
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SyntaxError 描述 bencode 数据的语法错误
+type SyntaxError struct {
+	Offset int64  // 读取了 Offset 个字节之后发现错误
+	Msg    string // 错误描述
+	err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("bencode: syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Unwrap 返回导致语法错误的原始错误，例如 ErrInt、io.ErrUnexpectedEOF
+func (e *SyntaxError) Unwrap() error {
+	return e.err
+}
+
+// UnmarshalTypeError 描述 bencode 值无法保存到对应的 Go 类型
+type UnmarshalTypeError struct {
+	Value  string       // bencode 值的描述，例如 "string"、"int 256"
+	Type   reflect.Type // 无法保存该值的 Go 类型
+	Offset int64        // 该值在输入中的偏移量
+	Field  string       // 从根节点到该值的路径，例如 info.files[3].length
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("bencode: cannot unmarshal %s into field %s of type %s (offset %d)", e.Value, e.Field, e.Type, e.Offset)
+	}
+	return fmt.Sprintf("bencode: cannot unmarshal %s into value of type %s (offset %d)", e.Value, e.Type, e.Offset)
+}
+
+// Unwrap 使 errors.Is(err, ErrType) 成立
+func (e *UnmarshalTypeError) Unwrap() error {
+	return ErrType
+}
+
+// prependField 在 Field 前面添加上一级的路径
+// key 为字典的 key，或者 "[i]" 形式的列表下标
+func (e *UnmarshalTypeError) prependField(key string) {
+	switch {
+	case e.Field == "":
+		e.Field = key
+	case strings.HasPrefix(e.Field, "["):
+		e.Field = key + e.Field
+	default:
+		e.Field = key + "." + e.Field
+	}
+}
+
+// withField 如果 err 是 *UnmarshalTypeError，为其添加上一级路径
+func withField(err error, key string) error {
+	if te, ok := err.(*UnmarshalTypeError); ok {
+		te.prependField(key)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package bencode
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		data   string
+		offset int64
+		target error
+	}{
+		{"d3:fooi1xe", 9, ErrInt},
+		{"l4:spam3x", 9, ErrColon},
+		{"d3:foo", 6, io.ErrUnexpectedEOF},
+		{"li1ex", 4, ErrUnknowByte},
+	}
+	for _, tt := range tests {
+		var v any
+		err := Unmarshal([]byte(tt.data), &v)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Fatalf("%q: want *SyntaxError, got %v", tt.data, err)
+		}
+		if se.Offset != tt.offset {
+			t.Errorf("%q: offset %d, want %d", tt.data, se.Offset, tt.offset)
+		}
+		if !errors.Is(err, tt.target) {
+			t.Errorf("%q: want %v, got %v", tt.data, tt.target, err)
+		}
+	}
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	var v struct {
+		Info struct {
+			Files []struct {
+				Length int64 `bencode:"length"`
+			} `bencode:"files"`
+		} `bencode:"info"`
+	}
+	data := []byte("d4:infod5:filesld6:lengthi1eed6:length1:xeeee")
+	err := Unmarshal(data, &v)
+	var te *UnmarshalTypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("want *UnmarshalTypeError, got %v", err)
+	}
+	if te.Field != "info.files[1].length" {
+		t.Errorf("field %q, want %q", te.Field, "info.files[1].length")
+	}
+	if te.Offset != 38 || te.Value != "string" || te.Type.String() != "int64" {
+		t.Errorf("unexpected error %+v", te)
+	}
+}
@@ -0,0 +1,237 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// badInputs 曾经导致死循环、panic 或者错误结果的输入，以及容易出错的边界情况
+var badInputs = []string{
+	"",
+	"d",
+	"l",
+	"i",
+	"-",
+	"e",
+	":",
+	"d3:foo",
+	"d3:fooxe",    // 旧版 GetRaw 忽略 scans 的错误，在这里死循环
+	"d3:fooi1xe",  // 同上，整数中的非法字节
+	"d1:ai1e1:b",  // key 之后没有值
+	"d1:ai1e1:be", // 同上，已经遇到字典结尾
+	"d1:a",        // 旧版 GetRaw 在 key 之后遇到 EOF
+	"le",          // 旧版 scansDL 在读到 e 之前先扫描一个值
+	"de",          // 同上
+	"l1:ae",       // 同上
+	"di1ei2ee",    // key 不是字符串
+	"d1:al",       // 未闭合的嵌套列表
+	"1:",          // 字符串长度超出数据
+	"99999999999999999999:a",
+	"i99999999999999999999e",
+	"i18446744073709551615e",
+	"i-9223372036854775809e",
+	"i-e",
+	"ie",
+	"i--1e",
+	"i1-e",
+	"d1:ai1e1:ai2ee", // 重复的 key
+	"d1:bi1e1:ai2ee", // 未排序的 key
+	"l" + string(bytes.Repeat([]byte("l"), 100)),
+	string(bytes.Repeat([]byte("l"), 100)) + string(bytes.Repeat([]byte("e"), 100)),
+	string(bytes.Repeat([]byte("d1:a"), 100)),
+}
+
+// seedCorpus 为 fuzz 测试添加真实的种子、tracker 响应和 DHT 消息
+func seedCorpus() [][]byte {
+	seeds := [][]byte{
+		rawTorrent,
+		trackerResp,
+		benchKRPC,
+		benchTorrent(4),
+		[]byte("d8:intervali1800e5:peers12:\x7f\x00\x00\x01\x1a\xe1\x0a\x00\x00\x02\x00\x50e"),
+		[]byte("d5:peersld2:ip9:127.0.0.14:porti6881eeee"),
+		[]byte("d1:ad2:id20:abcdefghij01234567896:target20:mnopqrstuvwxyz123456e1:q9:find_node1:t2:aa1:y1:qe"),
+	}
+	for _, in := range badInputs {
+		seeds = append(seeds, []byte(in))
+	}
+	return seeds
+}
+
+// withTimeout 在 d 时间内运行 f，超时说明 f 陷入了死循环
+func withTimeout(t testing.TB, d time.Duration, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatal("timed out, possible infinite loop")
+	}
+}
+
+func TestBadInputs(t *testing.T) {
+	for _, in := range badInputs {
+		data := []byte(in)
+		withTimeout(t, time.Second, func() {
+			var v any
+			Unmarshal(data, &v)
+			var tor benchTorrentFile
+			Unmarshal(data, &tor)
+			for _, path := range []string{"", "a", "foo", "info.files.0", "0.0"} {
+				GetRaw(data, path)
+				SetRaw(data, path, []byte("i1e"))
+			}
+			Valid(data)
+			Parse(data)
+			Index(data)
+			ToJSON(data, JSONOptions{})
+			Indent(io.Discard, data, IndentOptions{})
+			scanAll(data)
+		})
+	}
+}
+
+// scanAll 扫描 data 中的所有词法单元，返回 Scanner 最后返回的错误
+func scanAll(data []byte) ([]Token, error) {
+	s := NewScanner(data)
+	var toks []Token
+	for {
+		tok, err := s.Next()
+		if err != nil {
+			return toks, err
+		}
+		toks = append(toks, tok)
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range seedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tor benchTorrentFile
+		Unmarshal(data, &tor)
+		var raw RawMessage
+		Unmarshal(data, &raw)
+
+		var v any
+		if err := Unmarshal(data, &v); err != nil {
+			return
+		}
+		out, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal after Unmarshal: %v", err)
+		}
+		// 规范的输入重新编码之后应该完全相同
+		if Valid(data) == nil && !bytes.Equal(out, data) {
+			t.Fatalf("round trip changed canonical data:\n%q\n%q", data, out)
+		}
+
+		var again any
+		if err := Unmarshal(out, &again); err != nil {
+			t.Fatalf("Unmarshal of Marshal output: %v", err)
+		}
+		if err := Valid(out); err != nil {
+			t.Fatalf("Marshal produced non-canonical data %q: %v", out, err)
+		}
+	})
+}
+
+func FuzzGetRaw(f *testing.F) {
+	for _, seed := range seedCorpus() {
+		for _, path := range []string{"", "info", "info.files.0.path", "peers", "r.values.1", `name\.utf-8`} {
+			f.Add(seed, path)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte, path string) {
+		raw, err := GetRaw(data, path)
+		if err != nil {
+			return
+		}
+		if err := checkRaw(raw); err != nil {
+			t.Fatalf("GetRaw returned an incomplete value %q: %v", raw, err)
+		}
+
+		// 用原来的值替换不会改变数据
+		out, err := SetRaw(data, path, raw)
+		if err != nil {
+			t.Fatalf("SetRaw with the value from GetRaw: %v", err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Fatalf("SetRaw changed data:\n%q\n%q", data, out)
+		}
+	})
+}
+
+func FuzzScanner(f *testing.F) {
+	for _, seed := range seedCorpus() {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, strict bool) {
+		s := NewScanner(data)
+		if strict {
+			s.Strict()
+		}
+
+		eof := false
+		var prev int64 = -1
+		for n := 0; ; n++ {
+			// 每个词法单元至少消耗一个字节
+			if n > len(data) {
+				t.Fatalf("scanner returned more than %d tokens", len(data))
+			}
+			tok, err := s.Next()
+			if err == io.EOF {
+				if s.Depth() != 0 || s.Offset() != int64(len(data)) {
+					t.Fatalf("io.EOF at offset %d, depth %d", s.Offset(), s.Depth())
+				}
+				eof = true
+				break
+			}
+			if err != nil {
+				var se *SyntaxError
+				var le *LimitError
+				if !errors.As(err, &se) && !errors.As(err, &le) {
+					t.Fatalf("unexpected error type %T: %v", err, err)
+				}
+				break
+			}
+			if tok.Offset <= prev || tok.Offset >= int64(len(data)) {
+				t.Fatalf("token offset %d after %d", tok.Offset, prev)
+			}
+			prev = tok.Offset
+		}
+
+		// Valid 接受的数据在严格模式下一定可以扫描完
+		if strict && !eof && Valid(data) == nil {
+			t.Fatalf("Valid accepted %q but the strict scanner failed", data)
+		}
+	})
+}
+
+func FuzzJSON(f *testing.F) {
+	for _, seed := range seedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		js, err := ToJSON(data, JSONOptions{})
+		if err != nil {
+			return
+		}
+		out, err := FromJSON(js)
+		if err != nil {
+			t.Fatalf("FromJSON(%s): %v", js, err)
+		}
+		if Valid(data) == nil && !bytes.Equal(out, data) {
+			t.Fatalf("round trip changed canonical data:\n%q\n%q", data, out)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package bencode
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+)
+
+// 以下函数供 cmd/bencodegen 生成的代码使用，生成的代码与反射实现的 Marshal、Unmarshal 行为一致
+
+// WriteInt 将整数编码到 buf 中
+func WriteInt(buf *bytes.Buffer, i int64) {
+	buf.WriteByte('i')
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), i, 10))
+	buf.WriteByte('e')
+}
+
+// WriteUint 将无符号整数编码到 buf 中
+func WriteUint(buf *bytes.Buffer, u uint64) {
+	buf.WriteByte('i')
+	buf.Write(strconv.AppendUint(buf.AvailableBuffer(), u, 10))
+	buf.WriteByte('e')
+}
+
+// WriteBool 将 bool 编码为 i0e 或 i1e
+func WriteBool(buf *bytes.Buffer, b bool) {
+	if b {
+		buf.WriteString("i1e")
+	} else {
+		buf.WriteString("i0e")
+	}
+}
+
+// WriteString 将字符串编码到 buf 中
+func WriteString(buf *bytes.Buffer, s string) {
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(len(s)), 10))
+	buf.WriteByte(':')
+	buf.WriteString(s)
+}
+
+// WriteBytes 将 []byte 编码为字符串
+func WriteBytes(buf *bytes.Buffer, b []byte) {
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(len(b)), 10))
+	buf.WriteByte(':')
+	buf.Write(b)
+}
+
+// UnmarshalFunc 读取 data 中的第一个词法单元，交给 f 解析该值，用于实现 Unmarshaler
+// 使用与 Unmarshal 相同的默认选项；作为字段解码时应该同时实现 ScannerUnmarshaler，
+// Unmarshal、DecodeOptions 和 Decoder 会通过它共享外层的 Scanner，使用外层的严格模式和资源限制
+func UnmarshalFunc(data []byte, f func(s *Scanner, tok Token) error) error {
+	s := NewScanner(data)
+	tok, err := newDecodeState(s).next()
+	if err != nil {
+		return err
+	}
+	return f(s, tok)
+}
+
+// DecodeValue 将 tok 开始的值保存到 v 指向的变量中，规则与 Unmarshal 相同
+// v 为基本类型的指针时不使用反射
+func DecodeValue(s *Scanner, tok Token, v any) error {
+	if ok := decodeBasic(tok, v); ok {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrNotPtr
+	}
+	return newDecodeState(s).value(tok, rv.Elem())
+}
+
+// decodeBasic 尝试将整数或字符串直接保存到基本类型的变量中，失败时交给反射处理以得到相同的错误
+func decodeBasic(tok Token, v any) bool {
+	switch tok.Kind {
+	case TokenString:
+		switch p := v.(type) {
+		case *string:
+			*p = string(tok.Value)
+			return true
+		case *[]byte:
+			*p = append([]byte{}, tok.Value...)
+			return true
+		}
+
+	case TokenInt:
+		switch p := v.(type) {
+		case *int64:
+			i, err := tok.Int()
+			if err != nil {
+				return false
+			}
+			*p = i
+			return true
+		case *int:
+			i, err := tok.Int()
+			if err != nil || int64(int(i)) != i {
+				return false
+			}
+			*p = int(i)
+			return true
+		case *int32:
+			i, err := tok.Int()
+			if err != nil || int64(int32(i)) != i {
+				return false
+			}
+			*p = int32(i)
+			return true
+		case *uint64:
+			u, err := tok.Uint()
+			if err != nil {
+				return false
+			}
+			*p = u
+			return true
+		case *uint:
+			u, err := tok.Uint()
+			if err != nil || uint64(uint(u)) != u {
+				return false
+			}
+			*p = uint(u)
+			return true
+		case *uint32:
+			u, err := tok.Uint()
+			if err != nil || uint64(uint32(u)) != u {
+				return false
+			}
+			*p = uint32(u)
+			return true
+		case *uint16:
+			u, err := tok.Uint()
+			if err != nil || uint64(uint16(u)) != u {
+				return false
+			}
+			*p = uint16(u)
+			return true
+		case *bool:
+			i, err := tok.Int()
+			if err != nil || i != 0 && i != 1 {
+				return false
+			}
+			*p = i == 1
+			return true
+		}
+	}
+	return false
+}
+
+// TypeError 返回 tok 开始的值无法保存到 v 指向的变量的 *UnmarshalTypeError
+func TypeError(tok Token, v any) error {
+	return typeError(tok, reflect.TypeOf(v).Elem())
+}
+
+// WithField 如果 err 是 *UnmarshalTypeError，在其路径前添加字典的 key 或者 "[i]" 形式的列表下标
+func WithField(err error, key string) error {
+	return withField(err, key)
+}
@@ -0,0 +1,196 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWriteHelpers(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	WriteInt(buf, math.MinInt64)
+	WriteUint(buf, math.MaxUint64)
+	WriteBool(buf, true)
+	WriteBool(buf, false)
+	WriteString(buf, "spam")
+	WriteBytes(buf, []byte{})
+
+	want, err := Marshal([]any{int64(math.MinInt64), uint64(math.MaxUint64), true, false, "spam", []byte{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 去掉列表的 l 和 e
+	if got := buf.String(); got != string(want[1:len(want)-1]) {
+		t.Fatalf("got %q, want %q", got, want[1:len(want)-1])
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	inputs := []string{"i0e", "i1e", "i2e", "i-1e", "i255e", "i65536e", "i4294967296e",
+		"i9223372036854775807e", "i18446744073709551615e", "0:", "3:abc", "le"}
+	targets := []func() any{
+		func() any { return new(int) },
+		func() any { return new(int8) },
+		func() any { return new(int32) },
+		func() any { return new(int64) },
+		func() any { return new(uint) },
+		func() any { return new(uint8) },
+		func() any { return new(uint16) },
+		func() any { return new(uint32) },
+		func() any { return new(uint64) },
+		func() any { return new(bool) },
+		func() any { return new(string) },
+		func() any { return new([]byte) },
+		func() any { return new([3]byte) },
+	}
+	// 快速路径与 Unmarshal 的结果和错误相同
+	for _, in := range inputs {
+		for _, target := range targets {
+			got, want := target(), target()
+			s := NewScanner([]byte(in))
+			tok, _ := s.Next()
+			gerr := DecodeValue(s, tok, got)
+			werr := Unmarshal([]byte(in), want)
+			if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(gerr, werr) {
+				t.Errorf("%s into %T: got %v, %v, want %v, %v", in, got, reflect.ValueOf(got).Elem(), gerr, reflect.ValueOf(want).Elem(), werr)
+			}
+		}
+	}
+}
+
+func TestScannerSkipSub(t *testing.T) {
+	data := []byte("d1:ali1ei2ee1:bi3ee")
+	s := NewScanner(data)
+	s.Next()
+	s.Next()
+	tok, _ := s.Next()
+	raw, err := s.Skip(tok)
+	if err != nil || string(raw) != "li1ei2ee" {
+		t.Fatalf("Skip: %q, %v", raw, err)
+	}
+
+	// Sub 中的偏移量与外层一致
+	sub := s.Sub(tok, raw)
+	var v []string
+	err = DecodeValue(sub, mustNext(t, sub), &v)
+	var te *UnmarshalTypeError
+	if !errors.As(err, &te) || te.Offset != 5 || te.Field != "[0]" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func mustNext(t *testing.T, s *Scanner) Token {
+	t.Helper()
+	tok, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+// point 手动实现 BufferMarshaler 和 ScannerUnmarshaler，编码为两个整数的列表
+type point struct {
+	X, Y int64
+	via  string
+}
+
+func (p *point) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('l')
+	WriteInt(buf, p.X)
+	WriteInt(buf, p.Y)
+	buf.WriteByte('e')
+	return nil
+}
+
+func (p point) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := p.MarshalBencodeTo(buf)
+	return buf.Bytes(), err
+}
+
+func (p *point) UnmarshalBencodeFrom(s *Scanner, tok Token) error {
+	if tok.Kind != TokenList {
+		return TypeError(tok, p)
+	}
+	for _, v := range []*int64{&p.X, &p.Y} {
+		tok, err := s.Next()
+		if err != nil {
+			return err
+		}
+		err = DecodeValue(s, tok, v)
+		if err != nil {
+			return err
+		}
+	}
+	end, err := s.Next()
+	if err != nil {
+		return err
+	}
+	if end.Kind != TokenEnd {
+		return TypeError(tok, p)
+	}
+	p.via = "scanner"
+	return nil
+}
+
+func (p *point) UnmarshalBencode(data []byte) error {
+	err := UnmarshalFunc(data, p.UnmarshalBencodeFrom)
+	p.via = "raw"
+	return err
+}
+
+func TestBufferMarshalerScannerUnmarshaler(t *testing.T) {
+	v := struct {
+		P  point   `bencode:"p"`
+		PP *point  `bencode:"pp"`
+		L  []point `bencode:"l"`
+	}{P: point{X: 1, Y: 2}, PP: &point{X: 3}, L: []point{{Y: -1}}}
+	data, err := Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d1:llli0ei-1eee1:pli1ei2ee2:ppli3ei0eee"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+
+	v.P, v.PP, v.L = point{}, nil, nil
+	err = Unmarshal(data, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.P.via != "scanner" || v.PP.via != "scanner" || v.L[0].via != "scanner" {
+		t.Fatalf("UnmarshalBencodeFrom not used: %+v", v)
+	}
+
+	// 类型错误的偏移量与外层一致
+	err = Unmarshal([]byte("d1:pli1e1:xee"), &v)
+	var te *UnmarshalTypeError
+	if !errors.As(err, &te) || te.Offset != 8 || te.Field != "p" {
+		t.Fatalf("got %v", err)
+	}
+
+	// 嵌套的值使用外层的严格模式和资源限制
+	err = DecodeOptions{Strict: true}.Unmarshal([]byte("d1:pli01ei2eee"), &v)
+	if !errors.Is(err, ErrNonCanonical) {
+		t.Fatalf("got %v, want ErrNonCanonical", err)
+	}
+	err = DecodeOptions{Limits: &Limits{MaxElements: 3}}.Unmarshal([]byte("d1:llli1ei2eeee"), &v)
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxElements" {
+		t.Fatalf("got %v, want MaxElements", err)
+	}
+
+	var p point
+	err = p.UnmarshalBencode([]byte("li5ei6ee"))
+	if err != nil || p.X != 5 || p.Y != 6 {
+		t.Fatalf("got %+v, %v", p, err)
+	}
+	err = p.UnmarshalBencode(nil)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v, want unexpected EOF", err)
+	}
+}
@@ -1,54 +1,45 @@
 package bencode
 
 import (
-	"fmt"
 	"reflect"
+	"strings"
 )
 
-// set 设置 ref 最终指向的值为 val，无论 ret 是否是指针
-// 要求 val 和 ref 最终指向值类型相同或可转化
-// 你甚至可以使用 **int 初始化  ****int
-func set(ref reflect.Value, val any) error {
-	refEl := elem(ref)
-	vv, ok := val.(reflect.Value)
-	if !ok {
-		vv = reflect.ValueOf(val)
-	}
-	vvel := elem(vv)
-
-	if !vvel.CanConvert(refEl.Type()) {
-		return fmt.Errorf("can not convert %s to %s", vv.Type(), ref.Type())
-	}
+// tagOptions 结构体标签中 key 之后的选项，例如 `bencode:"name,omitempty"` 中的 omitempty
+type tagOptions string
 
-	refEl.Set(vvel.Convert(refEl.Type()))
-	return nil
+// parseTag 解析结构体标签，返回 key 和选项
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opts)
 }
 
-// zero 初始化 v 为零值，如果是多层指针，则会递归初始化
-func zero(v reflect.Value) {
-	if v.Kind() != reflect.Pointer {
-		v.Set(reflect.Zero(v.Type()))
-		return
-	}
-
-	if v.IsNil() {
-		v.Set(reflect.New(v.Type().Elem()))
+// Contains 判断是否包含选项 opt
+func (o tagOptions) Contains(opt string) bool {
+	s := string(o)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, ",")
+		if name == opt {
+			return true
+		}
 	}
-	zero(v.Elem())
+	return false
 }
 
-// elem 获取 ref 最终指向的值，如果是多层指针，则会递归获取最底层的值
-func elem(ref reflect.Value) reflect.Value {
-	el := ref
-	for {
-		if el.Kind() != reflect.Pointer {
-			break
-		}
-
-		if el.IsNil() {
-			zero(el)
-		}
-		el = el.Elem()
+// isEmpty 判断 omitempty 选项下 v 是否应该被忽略
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
 	}
-	return el
+	return false
 }
@@ -0,0 +1,130 @@
+package bencode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IndentOptions Indent 的选项，字段为零值时使用默认值
+type IndentOptions struct {
+	Indent    string // 每一层的缩进，默认为两个空格
+	MaxString int    // 文本字符串最多显示的字节数，默认为 64
+	MaxBinary int    // 二进制字符串最多显示的字节数（十六进制），默认为 16
+}
+
+func (o IndentOptions) withDefaults() IndentOptions {
+	if o.Indent == "" {
+		o.Indent = "  "
+	}
+	if o.MaxString <= 0 {
+		o.MaxString = 64
+	}
+	if o.MaxBinary <= 0 {
+		o.MaxBinary = 16
+	}
+	return o
+}
+
+// Indent 将 src 中的 bencode 值以树的形式写入 dst，用于人工查看 .torrent 文件和 tracker 响应
+// 每一行包含值的路径、类型、长度（字符串的字节数、列表和字典的元素个数）、在 src 中的起止偏移量和内容预览，
+// 过长的文本字符串和二进制字符串（例如 pieces）会被截断，二进制字符串以十六进制显示
+// 数据有误时依然输出已经扫描的部分，未完成的值标记为 incomplete，最后一行给出出错的位置，
+// 此时返回扫描时遇到的错误
+func Indent(dst io.Writer, src []byte, opts IndentOptions) error {
+	opts = opts.withDefaults()
+	r := newScanReader(src)
+	r.index = &indexer{}
+	scanErr := scans(r, nil)
+
+	buf := bytes.NewBuffer(nil)
+	if r.index.root != nil {
+		writeSpan(buf, src, r.index.root, 0, opts)
+	}
+
+	var err error
+	if scanErr != nil {
+		err = scanError(r, scanErr)
+		fmt.Fprintf(buf, "parsing stopped at offset %d: %v\n", r.off, err)
+	} else if rest := int64(len(src)) - r.off; rest > 0 {
+		err = &SyntaxError{Offset: r.off, Msg: ErrTrailingData.Error(), err: ErrTrailingData}
+		fmt.Fprintf(buf, "trailing data at offset %d: %d bytes\n", r.off, rest)
+	}
+
+	_, werr := dst.Write(buf.Bytes())
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+// writeSpan 写入 span 及其子节点，每个值占一行
+func writeSpan(buf *bytes.Buffer, src []byte, span *Span, depth int, opts IndentOptions) {
+	buf.WriteString(strings.Repeat(opts.Indent, depth))
+	if span.Path == "" {
+		buf.WriteString("(root)")
+	} else {
+		buf.WriteString(span.Path)
+	}
+
+	switch span.Type {
+	case BenInt:
+		fmt.Fprintf(buf, ": int @%d-%d", span.Start, span.End)
+		if !span.broken {
+			fmt.Fprintf(buf, " %s", src[span.Start+1:span.End-1])
+		}
+
+	case BenStr:
+		content := span.Content(src)
+		fmt.Fprintf(buf, ": string[%d] @%d-%d %s", len(content), span.Start, span.End, preview(content, opts))
+
+	case BenLst:
+		fmt.Fprintf(buf, ": list[%d] @%d-%d", len(span.Children), span.Start, span.End)
+
+	case BenDir:
+		fmt.Fprintf(buf, ": dict[%d] @%d-%d", len(span.Children), span.Start, span.End)
+	}
+	if span.broken {
+		buf.WriteString(" (incomplete)")
+	}
+	buf.WriteByte('\n')
+
+	for _, child := range span.Children {
+		writeSpan(buf, src, child, depth+1, opts)
+	}
+}
+
+// preview 返回字符串内容的预览，可打印的文本加上引号，其余以十六进制显示
+func preview(s []byte, opts IndentOptions) string {
+	if utf8.Valid(s) && printable(s) {
+		if len(s) <= opts.MaxString {
+			return strconv.Quote(string(s))
+		}
+		// 截断时不拆开多字节字符
+		n := opts.MaxString
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return strconv.Quote(string(s[:n])) + "..."
+	}
+
+	if len(s) <= opts.MaxBinary {
+		return "hex:" + hex.EncodeToString(s)
+	}
+	return "hex:" + hex.EncodeToString(s[:opts.MaxBinary]) + "..."
+}
+
+// printable 判断文本中是否只包含可打印字符
+func printable(s []byte) bool {
+	for _, r := range string(s) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
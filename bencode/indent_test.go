@@ -0,0 +1,62 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestIndent(t *testing.T) {
+	data := []byte("d8:announce9:http://a/4:infod6:lengthi5e6:pieces20:" + strings.Repeat("\xab", 20) + "ee")
+	buf := bytes.NewBuffer(nil)
+	err := Indent(buf, data, IndentOptions{MaxBinary: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(root): dict[2] @0-73
+  announce: string[9] @11-22 "http://a/"
+  info: dict[2] @28-72
+    info.length: int @37-40 5
+    info.pieces: string[20] @48-71 hex:abababab...
+`
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf, want)
+	}
+}
+
+func TestIndentCorrupt(t *testing.T) {
+	data := []byte("d4:infod4:name1:a5:filesli1ei2x")
+	buf := bytes.NewBuffer(nil)
+	err := Indent(buf, data, IndentOptions{})
+	if !errors.Is(err, ErrInt) {
+		t.Fatalf("got %v, want ErrInt", err)
+	}
+	want := `(root): dict[1] @0-31 (incomplete)
+  info: dict[2] @7-31 (incomplete)
+    info.name: string[1] @14-17 "a"
+    info.files: list[2] @24-31 (incomplete)
+      info.files.0: int @25-28 1
+      info.files.1: int @28-31 (incomplete)
+parsing stopped at offset 31: bencode: syntax error at offset 31: type error, not int: unexpected byte 'x'
+`
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf, want)
+	}
+
+	buf.Reset()
+	err = Indent(buf, []byte("i1e3:abc"), IndentOptions{})
+	if !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("got %v, want ErrTrailingData", err)
+	}
+	if !strings.HasSuffix(buf.String(), "trailing data at offset 3: 5 bytes\n") {
+		t.Fatalf("got\n%s", buf)
+	}
+
+	buf.Reset()
+	err = Indent(buf, []byte("l5:ab"), IndentOptions{})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
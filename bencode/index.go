@@ -0,0 +1,104 @@
+package bencode
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// Span 记录一个值在原始数据中的位置，Children 为列表或字典中的元素
+// 通过 data[Start:End] 可以直接得到该值的原始数据，不需要重新解析
+type Span struct {
+	Path     string  // 从根节点到该值的路径，格式与 GetRaw 相同
+	Key      string  // 在字典中的 key 或者在列表中的下标，根节点为空
+	Type     BenType // 值的类型
+	Start    int64   // 该值第一个字节的偏移量
+	End      int64   // 该值最后一个字节之后的偏移量
+	Children []*Span // 列表或字典中的元素，按照在数据中出现的顺序排列
+
+	broken bool // 扫描该值时出错，End 为出错的位置
+}
+
+// Index 扫描 data 中的一个完整的值，返回所有值的位置，data 中值之后不允许有多余的数据
+func Index(data []byte) (*Span, error) {
+	r := newScanReader(data)
+	r.index = &indexer{}
+	err := scans(r, nil)
+	if err != nil {
+		return nil, scanError(r, err)
+	}
+	if _, err := r.peek(); err != io.EOF {
+		return nil, &SyntaxError{Offset: r.off, Msg: ErrTrailingData.Error(), err: ErrTrailingData}
+	}
+	return r.index.root, nil
+}
+
+// Find 返回 path 对应的值的位置，路径格式与 GetRaw 相同
+func (s *Span) Find(path string) (*Span, bool) {
+	cur := s
+	for _, seg := range splitPath(path) {
+		var next *Span
+		for _, child := range cur.Children {
+			if child.Key == seg {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		cur = next
+	}
+	return cur, true
+}
+
+// Raw 返回该值在 data 中的原始数据，data 必须是传给 Index 的数据
+func (s *Span) Raw(data []byte) []byte {
+	return data[s.Start:s.End]
+}
+
+// Content 返回字符串的内容（不包含长度前缀），其他类型返回原始数据
+func (s *Span) Content(data []byte) []byte {
+	raw := s.Raw(data)
+	if s.Type != BenStr {
+		return raw
+	}
+	_, content, _ := bytes.Cut(raw, []byte{':'})
+	return content
+}
+
+// indexer 在 scans 扫描的过程中构建 Span 树
+type indexer struct {
+	root  *Span
+	stack []*Span
+	key   string // 最近读取的字典 key
+}
+
+// open 记录一个新的值的开始位置
+func (idx *indexer) open(typ BenType, off int64) {
+	span := &Span{Type: typ, Start: off}
+	if n := len(idx.stack); n > 0 {
+		parent := idx.stack[n-1]
+		if parent.Type == BenDir {
+			span.Key = idx.key
+		} else {
+			span.Key = strconv.Itoa(len(parent.Children))
+		}
+		span.Path = joinPath([]string{span.Key})
+		if parent.Path != "" {
+			span.Path = parent.Path + "." + span.Path
+		}
+		parent.Children = append(parent.Children, span)
+	} else {
+		idx.root = span
+	}
+	idx.stack = append(idx.stack, span)
+}
+
+// close 记录当前值的结束位置，broken 表示扫描该值时出错
+func (idx *indexer) close(off int64, broken bool) {
+	n := len(idx.stack)
+	idx.stack[n-1].End = off
+	idx.stack[n-1].broken = broken
+	idx.stack = idx.stack[:n-1]
+}
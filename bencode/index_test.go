@@ -0,0 +1,50 @@
+package bencode
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	root, err := Index(rawTorrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Type != BenDir || root.Start != 0 || root.End != int64(len(rawTorrent)) {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	if len(root.Children) != 4 {
+		t.Fatalf("unexpected children %d", len(root.Children))
+	}
+
+	for _, path := range []string{"", "announce", "info", "info.files.1.path.0", `info.name\.utf-8`, "x"} {
+		span, ok := root.Find(path)
+		if !ok {
+			t.Errorf("%q: not found", path)
+			continue
+		}
+		if span.Path != path {
+			t.Errorf("%q: path %q", path, span.Path)
+		}
+		raw, err := GetRaw(rawTorrent, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(span.Raw(rawTorrent)) != string(raw) {
+			t.Errorf("%q: got %q, want %q", path, span.Raw(rawTorrent), raw)
+		}
+	}
+
+	name, _ := root.Find("info.name")
+	if name.Type != BenStr || string(name.Content(rawTorrent)) != "dir" {
+		t.Fatalf("unexpected name %+v", name)
+	}
+	if _, ok := root.Find("info.files.2"); ok {
+		t.Fatal("want not found")
+	}
+
+	if _, err := Index([]byte("d3:foo")); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("want unexpected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+package bencode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidJSON = errors.New("json can not be converted to bencode")
+)
+
+// JSONBinary 不是合法 UTF-8 的字符串在 JSON 中的表示方式
+type JSONBinary uint8
+
+const (
+	JSONHex    JSONBinary = iota // {"$hex": "..."}
+	JSONBase64                   // {"$base64": "..."}，使用标准 base64 编码
+)
+
+// JSONOptions ToJSON 的选项，零值表示使用 JSONHex 并输出紧凑的 JSON
+type JSONOptions struct {
+	Binary JSONBinary
+	Indent string // 不为空时每一层使用 Indent 缩进
+}
+
+// ToJSON 将一个 bencode 值转换为 JSON，字典的 key 保持在输入中的顺序，用于调试和展示
+// 整数转换为 JSON 数字，合法的 UTF-8 字符串转换为 JSON 字符串，其余字符串（例如 pieces、peers）
+// 转换为 {"$hex": "..."} 或 {"$base64": "..."}
+// key 不是合法 UTF-8 的字典，以及第一个 key 以 $ 开头的字典，转换为 {"$dict": [[key, value], ...]}，避免与上述表示混淆
+// 输入中规范的整数和字符串长度（key 是否排序不影响）经过 FromJSON 转换后可以得到完全相同的数据
+func ToJSON(data []byte, opts JSONOptions) ([]byte, error) {
+	v, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = v.writeJSON(buf, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Indent == "" {
+		return buf.Bytes(), nil
+	}
+
+	out := bytes.NewBuffer(nil)
+	err = json.Indent(out, buf.Bytes(), "", opts.Indent)
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeJSON 将 v 转换为紧凑的 JSON 写入 buf
+func (v Value) writeJSON(buf *bytes.Buffer, opts JSONOptions) error {
+	switch v.Type() {
+	case BenInt:
+		i, _ := v.Int()
+		buf.WriteString(strconv.FormatInt(i, 10))
+		return nil
+
+	case BenStr:
+		s, _ := v.Bytes()
+		writeJSONString(buf, s, opts)
+		return nil
+
+	case BenLst:
+		list, _ := v.List()
+		buf.WriteByte('[')
+		for i, el := range list {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := el.writeJSON(buf, opts)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+
+	case BenDir:
+		keys := v.Keys()
+		pairs := !jsonKeysSafe(keys)
+		if pairs {
+			buf.WriteString(`{"$dict":[`)
+		} else {
+			buf.WriteByte('{')
+		}
+
+		var err error
+		i := 0
+		v.Range(func(key string, val Value) bool {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			i++
+			if pairs {
+				buf.WriteByte('[')
+				writeJSONString(buf, []byte(key), opts)
+				buf.WriteByte(',')
+			} else {
+				writeJSONString(buf, []byte(key), opts)
+				buf.WriteByte(':')
+			}
+			err = val.writeJSON(buf, opts)
+			if pairs {
+				buf.WriteByte(']')
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if pairs {
+			buf.WriteString("]}")
+		} else {
+			buf.WriteByte('}')
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: empty value", ErrUnsupportedType)
+	}
+}
+
+// jsonKeysSafe 判断字典能否直接转换为 JSON 对象
+func jsonKeysSafe(keys []string) bool {
+	// FromJSON 根据第一个 key 识别 $hex、$base64 和 $dict
+	if len(keys) > 0 && strings.HasPrefix(keys[0], "$") {
+		return false
+	}
+	for _, k := range keys {
+		if !utf8.ValidString(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeJSONString 将字符串写入 buf，不是合法 UTF-8 时使用 opts 指定的表示方式
+func writeJSONString(buf *bytes.Buffer, s []byte, opts JSONOptions) {
+	if !utf8.Valid(s) {
+		if opts.Binary == JSONBase64 {
+			buf.WriteString(`{"$base64":"` + base64.StdEncoding.EncodeToString(s) + `"}`)
+		} else {
+			buf.WriteString(`{"$hex":"` + hex.EncodeToString(s) + `"}`)
+		}
+		return
+	}
+
+	// 不转义 HTML 字符，tracker 地址中的 & 保持原样
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(string(s))
+	buf.Truncate(buf.Len() - 1) // Encode 会在末尾添加换行
+}
+
+// FromJSON 将 ToJSON 输出的 JSON 转换回 bencode 数据，字典的 key 保持在 JSON 中的顺序
+// JSON 中不能包含小数、true、false 和 null
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	buf := bytes.NewBuffer(nil)
+	err := fromJSON(dec, buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, ErrTrailingData)
+	}
+	return buf.Bytes(), nil
+}
+
+// fromJSON 从 dec 中读取一个 JSON 值，转换为 bencode 写入 buf
+func fromJSON(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Number:
+		i, err := strconv.ParseInt(string(t), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: number %s is not an int64", ErrInvalidJSON, t)
+		}
+		return encodeInt(buf, i)
+
+	case string:
+		return encodeString(buf, t)
+
+	case json.Delim:
+		switch t {
+		case '[':
+			buf.WriteByte('l')
+			for dec.More() {
+				err := fromJSON(dec, buf)
+				if err != nil {
+					return err
+				}
+			}
+			err := jsonEnd(dec)
+			if err != nil {
+				return err
+			}
+			return buf.WriteByte('e')
+
+		case '{':
+			return fromJSONObject(dec, buf)
+		}
+	}
+	return fmt.Errorf("%w: unexpected %v", ErrInvalidJSON, tok)
+}
+
+// fromJSONObject 转换 JSON 对象，dec 需要位于 { 之后
+func fromJSONObject(dec *json.Decoder, buf *bytes.Buffer) error {
+	if !dec.More() {
+		buf.WriteString("de")
+		return jsonEnd(dec)
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	key := tok.(string)
+	switch key {
+	case "$hex", "$base64":
+		s, err := fromJSONBinary(dec, key)
+		if err != nil {
+			return err
+		}
+		return encodeString(buf, string(s))
+
+	case "$dict":
+		return fromJSONPairs(dec, buf)
+	}
+
+	buf.WriteByte('d')
+	seen := map[string]bool{}
+	for {
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidJSON, key)
+		}
+		seen[key] = true
+		encodeString(buf, key)
+		err = fromJSON(dec, buf)
+		if err != nil {
+			return err
+		}
+
+		if !dec.More() {
+			buf.WriteByte('e')
+			return jsonEnd(dec)
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		key = tok.(string)
+	}
+}
+
+// jsonEnd 读取数组或对象的结束符号
+func jsonEnd(dec *json.Decoder) error {
+	_, err := dec.Token()
+	return err
+}
+
+// fromJSONBinary 读取 {"$hex": "..."} 或 {"$base64": "..."} 中的字符串，dec 需要位于 key 之后
+func fromJSONBinary(dec *json.Decoder, key string) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := tok.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must be a string", ErrInvalidJSON, key)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected key after %s", ErrInvalidJSON, key)
+	}
+	err = jsonEnd(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []byte
+	if key == "$hex" {
+		res, err = hex.DecodeString(s)
+	} else {
+		res, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidJSON, key, err)
+	}
+	return res, nil
+}
+
+// fromJSONPairs 转换 {"$dict": [[key, value], ...]}，dec 需要位于 $dict 之后
+func fromJSONPairs(dec *json.Decoder, buf *bytes.Buffer) error {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return fmt.Errorf("%w: $dict must be a list of pairs", ErrInvalidJSON)
+	}
+
+	buf.WriteByte('d')
+	seen := map[string]bool{}
+	for dec.More() {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return fmt.Errorf("%w: $dict must be a list of pairs", ErrInvalidJSON)
+		}
+		key, err := fromJSONKey(dec)
+		if err != nil {
+			return err
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidJSON, key)
+		}
+		seen[string(key)] = true
+		encodeString(buf, string(key))
+
+		err = fromJSON(dec, buf)
+		if err != nil {
+			return err
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim(']') {
+			return fmt.Errorf("%w: $dict must be a list of pairs", ErrInvalidJSON)
+		}
+	}
+	err := jsonEnd(dec)
+	if err != nil {
+		return err
+	}
+
+	if dec.More() {
+		return fmt.Errorf("%w: unexpected key after $dict", ErrInvalidJSON)
+	}
+	buf.WriteByte('e')
+	return jsonEnd(dec)
+}
+
+// fromJSONKey 读取 $dict 中的 key，可以是字符串或者 {"$hex": "..."}、{"$base64": "..."}
+func fromJSONKey(dec *json.Decoder) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case string:
+		return []byte(t), nil
+	case json.Delim:
+		if t == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if key, ok := tok.(string); ok && (key == "$hex" || key == "$base64") {
+				return fromJSONBinary(dec, key)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: $dict key must be a string", ErrInvalidJSON)
+}
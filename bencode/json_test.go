@@ -0,0 +1,84 @@
+package bencode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	cases := []struct {
+		data string
+		json string
+	}{
+		{"i-42e", `-42`},
+		{"4:spam", `"spam"`},
+		{"3:\xff\x00\x01", `{"$hex":"ff0001"}`},
+		{"le", `[]`},
+		{"de", `{}`},
+		{"d1:bi1e1:a1:xe", `{"b":1,"a":"x"}`},
+		{"d4:$hex2:abe", `{"$dict":[["$hex","ab"]]}`},
+		{"d4:$hexi1e1:xi2ee", `{"$dict":[["$hex",1],["x",2]]}`},
+		{"d1:\xffi1ee", `{"$dict":[[{"$hex":"ff"},1]]}`},
+		{"d8:announce15:http://a/?x=1&y5:peers6:\x7f\x00\x00\x01\x1a\xe1e", `{"announce":"http://a/?x=1&y","peers":{"$hex":"7f0000011ae1"}}`},
+	}
+
+	for _, c := range cases {
+		js, err := ToJSON([]byte(c.data), JSONOptions{})
+		if err != nil {
+			t.Errorf("%q: %v", c.data, err)
+			continue
+		}
+		if string(js) != c.json {
+			t.Errorf("%q: got %s, want %s", c.data, js, c.json)
+		}
+		data, err := FromJSON(js)
+		if err != nil {
+			t.Errorf("%s: %v", js, err)
+			continue
+		}
+		if string(data) != c.data {
+			t.Errorf("%s: got %q, want %q", js, data, c.data)
+		}
+	}
+}
+
+func TestJSONOptions(t *testing.T) {
+	js, err := ToJSON([]byte("d6:pieces2:\xab\xcde"), JSONOptions{Binary: JSONBase64, Indent: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"pieces\": {\n    \"$base64\": \"q80=\"\n  }\n}"
+	if string(js) != want {
+		t.Fatalf("got %s, want %s", js, want)
+	}
+	data, err := FromJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "d6:pieces2:\xab\xcde" {
+		t.Fatalf("got %q", data)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, js := range []string{`1.5`, `true`, `null`, `{"a":1,"a":2}`, `{"$hex":"zz"}`, `{"$hex":"ff","x":1}`, `1 2`} {
+		_, err := FromJSON([]byte(js))
+		if !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("%s: got %v, want ErrInvalidJSON", js, err)
+		}
+	}
+}
+
+func TestJSONTorrent(t *testing.T) {
+	js, err := ToJSON(rawTorrent, JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := FromJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(rawTorrent) {
+		t.Fatalf("round trip changed the torrent:\n%q\n%q", data, rawTorrent)
+	}
+}
@@ -0,0 +1,33 @@
+package bencode
+
+import "fmt"
+
+// Limits 解码时的资源限制，用于防止恶意输入耗尽内存或栈空间，字段为 0 表示不限制
+type Limits struct {
+	MaxStringLen int   // 单个字符串的最大长度
+	MaxDepth     int   // 列表和字典的最大嵌套深度
+	MaxElements  int   // 单个值中包含的最多元素个数（整数、字符串、列表、字典都计为一个元素）
+	MaxInputSize int64 // 单个值的最大字节数
+}
+
+// DefaultLimits Unmarshal、Valid 和 NewDecoder 默认使用的限制
+// 足以解析 tracker 响应、扩展协议消息和常见的 .torrent 文件
+// 修改它会影响所有使用默认值的调用，并且与解码并发时不安全，只应在程序初始化时修改；
+// 需要不同的限制时使用 DecodeOptions.Limits 或 Decoder.SetLimits
+var DefaultLimits = Limits{
+	MaxStringLen: 32 << 20,
+	MaxDepth:     64,
+	MaxElements:  1 << 20,
+	MaxInputSize: 64 << 20,
+}
+
+// LimitError 解码时超出了 Limits 中的限制
+type LimitError struct {
+	Limit  string // 超出的限制，例如 "MaxDepth"
+	Max    int64  // 限制的值
+	Offset int64  // 读取了 Offset 个字节之后超出限制
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("bencode: %s limit %d exceeded at offset %d", e.Limit, e.Max, e.Offset)
+}
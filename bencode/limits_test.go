@@ -0,0 +1,79 @@
+package bencode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		data   string
+		limits Limits
+		limit  string
+	}{
+		{"999999999999:a", DefaultLimits, "MaxStringLen"},
+		{strings.Repeat("l", 100) + strings.Repeat("e", 100), DefaultLimits, "MaxDepth"},
+		{"li1ei2ei3ee", Limits{MaxElements: 3}, "MaxElements"},
+		{"4:spam", Limits{MaxInputSize: 4}, "MaxInputSize"},
+		{"li1ei2ei3ee", Limits{MaxInputSize: 8}, "MaxInputSize"},
+	}
+	for _, tt := range tests {
+		dec := NewDecoder(strings.NewReader(tt.data))
+		dec.SetLimits(tt.limits)
+		var v any
+		err := dec.Decode(&v)
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Errorf("%.20q: want *LimitError, got %v", tt.data, err)
+			continue
+		}
+		if le.Limit != tt.limit {
+			t.Errorf("%.20q: limit %s, want %s", tt.data, le.Limit, tt.limit)
+		}
+	}
+
+	// 深度限制只计算嵌套层数，同一层的多个列表不受影响
+	var v any
+	data := "l" + strings.Repeat("lee", 100) + "e"
+	if err := Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeOptionsLimits(t *testing.T) {
+	// 超过 DefaultLimits.MaxElements 的列表，不修改全局变量也可以解析
+	data := "l" + strings.Repeat("i1e", DefaultLimits.MaxElements) + "e"
+	var v []int
+	err := Unmarshal([]byte(data), &v)
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxElements" {
+		t.Fatalf("default limits: got %v", err)
+	}
+	unlimited := DecodeOptions{Limits: &Limits{}}
+	err = unlimited.Unmarshal([]byte(data), &v)
+	if err != nil || len(v) != DefaultLimits.MaxElements {
+		t.Fatalf("unlimited: %v, %d elements", err, len(v))
+	}
+	if _, err := unlimited.Parse([]byte(data)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	n, err := unlimited.DecodePrefix([]byte(data+"raw"), &v)
+	if err != nil || n != len(data) {
+		t.Fatalf("DecodePrefix: %d, %v", n, err)
+	}
+
+	// 更严格的限制对所有入口都生效
+	small := DecodeOptions{Limits: &Limits{MaxElements: 3}}
+	var x any
+	errs := []error{small.Unmarshal([]byte("li1ei2ei3ee"), &x)}
+	_, err = small.Parse([]byte("li1ei2ei3ee"))
+	errs = append(errs, err)
+	_, err = small.DecodePrefix([]byte("li1ei2ei3eeraw"), &x)
+	errs = append(errs, err)
+	for i, err := range errs {
+		if !errors.As(err, &le) || le.Limit != "MaxElements" {
+			t.Errorf("entry %d: got %v, want MaxElements", i, err)
+		}
+	}
+}
@@ -5,17 +5,36 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"unicode"
+	"sort"
 )
 
 var (
 	ErrUnsupportedType = errors.New("unsupported type")
+	ErrKeyConflict     = errors.New("fields with the same key have different values")
 )
 
-// TODO: support type any
+// Marshaler 由可以自行编码为 bencode 数据的类型实现
+// MarshalBencode 需要返回一个完整的 bencode 值
+type Marshaler interface {
+	MarshalBencode() ([]byte, error)
+}
+
+// BufferMarshaler 由可以直接将值编码到 buf 中的类型实现，例如 cmd/bencodegen 生成的类型
+// 编码可以取地址的值时优先于 Marshaler 使用，避免复制 MarshalBencode 返回的数据
+type BufferMarshaler interface {
+	MarshalBencodeTo(buf *bytes.Buffer) error
+}
+
 // Marshal marshal any type to bencode bytes
+// 支持任意宽度的整数，bool 编码为 i0e/i1e，[]byte 和 [N]byte 编码为字符串
+// 输出总是规范的：字典和结构体的 key 按原始字节升序排列，相同的输入总是得到相同的输出
+// 结构体中多个字段使用同一个 key 时输出非零值的字段，全部为零值时输出最后一个，
+// 多个字段都不为零值且编码结果不同时返回 ErrKeyConflict，例如修改了解析后的值但没有清空对应的 RawMessage
 func Marshal(a any) ([]byte, error) {
-	ref := elem(reflect.ValueOf(a))
+	ref := reflect.ValueOf(a)
+	if !ref.IsValid() {
+		return nil, fmt.Errorf("%w: nil", ErrUnsupportedType)
+	}
 	buf := bytes.NewBuffer(nil)
 
 	err := marshal(buf, ref)
@@ -26,109 +45,318 @@ func Marshal(a any) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// encoderFunc 将 v 编码到 buf 中，每种类型的编码函数只构造一次
+type encoderFunc func(buf *bytes.Buffer, v reflect.Value) error
+
+var (
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	bufferMarshalerType = reflect.TypeOf((*BufferMarshaler)(nil)).Elem()
+)
+
 func marshal(buf *bytes.Buffer, ref reflect.Value) error {
-	switch ref.Kind() {
+	return typeEncoder(ref.Type())(buf, ref)
+}
+
+// newTypeEncoder 构造类型 t 的编码函数
+func newTypeEncoder(t reflect.Type) encoderFunc {
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return newKindEncoder(t)
+	}
+
+	var enc encoderFunc
+	switch {
+	case t.Implements(marshalerType):
+		enc = marshalerEncoder
+	case reflect.PointerTo(t).Implements(marshalerType):
+		enc = condAddrEncoder(addrMarshalerEncoder, newKindEncoder(t))
+	default:
+		enc = newKindEncoder(t)
+	}
+	if reflect.PointerTo(t).Implements(bufferMarshalerType) {
+		return condAddrEncoder(addrBufferMarshalerEncoder, enc)
+	}
+	return enc
+}
+
+// newKindEncoder 根据 t 的 Kind 构造编码函数
+func newKindEncoder(t reflect.Type) encoderFunc {
+	switch t.Kind() {
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return encodeInt(buf, ref.Int())
+		return intEncoder
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintEncoder
+
+	case reflect.Bool:
+		return boolEncoder
 
 	case reflect.String:
-		return encodeString(buf, ref.String())
+		return stringEncoder
 
 	case reflect.Slice, reflect.Array:
-		return marshalList(buf, ref)
+		// []byte 和 [N]byte 编码为字符串
+		if t.Elem().Kind() == reflect.Uint8 {
+			return bytesEncoder
+		}
+		return newListEncoder(t)
 
 	case reflect.Map:
-		return marshalDict(buf, ref)
+		if t.Key().Kind() != reflect.String {
+			return unsupportedEncoder
+		}
+		return newDictEncoder(t)
 
 	case reflect.Struct:
-		return marshalStruct(buf, ref)
+		return newStructEncoder(t)
+
+	case reflect.Interface:
+		return interfaceEncoder
+
+	case reflect.Pointer:
+		return newPtrEncoder(t)
 
 	default:
-		fmt.Println(ref.CanInt(), ref.CanUint(), ref.CanConvert(reflect.TypeOf("")))
-		return ErrUnsupportedType
+		return unsupportedEncoder
 	}
 }
 
-func marshalList(buf *bytes.Buffer, ref reflect.Value) error {
-	refEl := elem(ref)
-	length := refEl.Len()
-	err := buf.WriteByte('l')
+func marshalerEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	data, err := v.Interface().(Marshaler).MarshalBencode()
 	if err != nil {
 		return err
 	}
+	_, err = buf.Write(data)
+	return err
+}
 
-	for i := 0; i < length; i++ {
-		iel := elem(refEl.Index(i))
-		err := marshal(buf, iel)
-		if err != nil {
-			return err
+// addrBufferMarshalerEncoder 编码指针类型实现了 BufferMarshaler 的值，v 必须可以取地址
+func addrBufferMarshalerEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return v.Addr().Interface().(BufferMarshaler).MarshalBencodeTo(buf)
+}
+
+// addrMarshalerEncoder 编码指针类型实现了 Marshaler 的值，v 必须可以取地址
+func addrMarshalerEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return marshalerEncoder(buf, v.Addr())
+}
+
+// condAddrEncoder v 可以取地址时使用 addr，否则使用 other
+func condAddrEncoder(addr, other encoderFunc) encoderFunc {
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		if v.CanAddr() {
+			return addr(buf, v)
 		}
+		return other(buf, v)
 	}
+}
 
-	return buf.WriteByte('e')
+func intEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return encodeInt(buf, v.Int())
 }
 
-func marshalDict(buf *bytes.Buffer, ref reflect.Value) error {
-	refEl := elem(ref)
-	length := refEl.Len()
-	err := buf.WriteByte('d')
-	if err != nil {
-		return err
+func uintEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return encodeUint(buf, v.Uint())
+}
+
+func boolEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	if v.Bool() {
+		return encodeInt(buf, 1)
+	}
+	return encodeInt(buf, 0)
+}
+
+func stringEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return encodeString(buf, v.String())
+}
+
+func bytesEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return encodeString(buf, string(byteSlice(v)))
+}
+
+func unsupportedEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+func interfaceEncoder(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		return fmt.Errorf("%w: nil interface", ErrUnsupportedType)
 	}
+	el := v.Elem()
+	if m, ok := el.Interface().(Marshaler); ok {
+		return marshalerEncoder(buf, reflect.ValueOf(m))
+	}
+	return marshal(buf, el)
+}
 
-	for i := 0; i < length; i++ {
-		k := refEl.MapKeys()[i]
-		v := refEl.MapIndex(k)
-		vel := elem(v)
-		if vel.Kind() == reflect.String && vel.IsZero() {
-			continue
+// newPtrEncoder 编码指针指向的值，nil 指针编码为指向类型的零值
+func newPtrEncoder(t reflect.Type) encoderFunc {
+	enc := typeEncoder(t.Elem())
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		if v.IsNil() {
+			v = reflect.New(t.Elem())
 		}
+		return enc(buf, v.Elem())
+	}
+}
+
+// byteSlice 返回元素类型为 uint8 的 slice 或 array 的内容
+func byteSlice(ref reflect.Value) []byte {
+	if ref.Kind() == reflect.Slice && ref.Type().Elem() == reflect.TypeOf(byte(0)) {
+		return ref.Bytes()
+	}
+	b := make([]byte, ref.Len())
+	for i := range b {
+		b[i] = byte(ref.Index(i).Uint())
+	}
+	return b
+}
 
-		err := encodeString(buf, k.String())
+func newListEncoder(t reflect.Type) encoderFunc {
+	enc := typeEncoder(t.Elem())
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		err := buf.WriteByte('l')
 		if err != nil {
 			return err
 		}
-		err = marshal(buf, elem(v))
+
+		for i := 0; i < v.Len(); i++ {
+			err := enc(buf, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+
+		return buf.WriteByte('e')
+	}
+}
+
+// newDictEncoder 编码 map，key 按原始字节升序排列
+func newDictEncoder(t reflect.Type) encoderFunc {
+	enc := typeEncoder(t.Elem())
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		err := buf.WriteByte('d')
 		if err != nil {
 			return err
 		}
-	}
 
-	return buf.WriteByte('e')
-}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			err := encodeString(buf, k.String())
+			if err != nil {
+				return err
+			}
+			err = enc(buf, v.MapIndex(k))
+			if err != nil {
+				return err
+			}
+		}
 
-func marshalStruct(buf *bytes.Buffer, ref reflect.Value) error {
-	refEl := elem(ref)
-	length := refEl.NumField()
-	err := buf.WriteByte('d')
-	if err != nil {
-		return err
+		return buf.WriteByte('e')
 	}
+}
 
-	for i := 0; i < length; i++ {
-		fieldName := refEl.Type().Field(i).Name
-		if unicode.IsLower(rune(fieldName[0])) {
-			continue
-		}
-		tag := refEl.Type().Field(i).Tag.Get("bencode")
-		if tag == "-" {
-			continue
-		}
-		if tag == "" {
-			tag = fieldName
-		}
+// newStructEncoder 编码结构体，字段按 key 的原始字节升序排列，值为 nil 指针的字段会被忽略
+// 多个字段对应同一个 key 时（例如同时使用 RawMessage 和解析后的结构体保存同一个值），
+// 使用第一个非零值的字段，全部为零值时使用最后一个，多个非零值的编码结果不同时返回 ErrKeyConflict
+func newStructEncoder(t reflect.Type) encoderFunc {
+	plan := cachedFields(t)
+	encs := make([]encoderFunc, len(plan.fields))
+	for i, f := range plan.fields {
+		encs[i] = typeEncoder(f.typ)
+	}
 
-		err = encodeString(buf, tag)
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		err := buf.WriteByte('d')
 		if err != nil {
 			return err
 		}
-		field := elem(refEl.Field(i))
-		err = marshal(buf, field)
+
+		for _, key := range plan.keys {
+			idx := plan.byKey[key]
+			i := -1
+			var fv reflect.Value
+			var encoded [][]byte // 非零值字段的编码结果
+			for _, j := range idx {
+				// 位于 nil 嵌入指针中的字段视为不存在
+				jv, ok := fieldByIndex(v, plan.fields[j].index, false)
+				if !ok {
+					continue
+				}
+				if jv.IsZero() {
+					if len(encoded) == 0 {
+						i, fv = j, jv
+					}
+					continue
+				}
+				if len(encoded) == 0 {
+					i, fv = j, jv
+				}
+				if len(idx) > 1 {
+					b := bytes.NewBuffer(nil)
+					err = encs[j](b, jv)
+					if err != nil {
+						return err
+					}
+					encoded = append(encoded, b.Bytes())
+				}
+			}
+			err = sameKey(key, encoded)
+			if err != nil {
+				return err
+			}
+
+			// nil 指针表示可选的 key 不存在
+			if i < 0 || fv.Kind() == reflect.Pointer && fv.IsNil() {
+				continue
+			}
+			if plan.fields[idx[0]].omitEmpty && isEmpty(fv) {
+				continue
+			}
+			err = encodeString(buf, key)
+			if err != nil {
+				return err
+			}
+			if len(encoded) > 0 {
+				buf.Write(encoded[0])
+				continue
+			}
+			err = encs[i](buf, fv)
+			if err != nil {
+				return err
+			}
+		}
+
+		return buf.WriteByte('e')
+	}
+}
+
+// SameKey 检查多个字段对应同一个 key 时的值，values 为所有非零值字段的指针，
+// 编码结果不同时返回 ErrKeyConflict，供 cmd/bencodegen 生成的代码使用
+func SameKey(key string, values ...any) error {
+	if len(values) < 2 {
+		return nil
+	}
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		b, err := Marshal(v)
 		if err != nil {
 			return err
 		}
+		encoded[i] = b
 	}
+	return sameKey(key, encoded)
+}
 
-	return buf.WriteByte('e')
+// sameKey 检查同一个 key 的多个编码结果是否相同
+func sameKey(key string, encoded [][]byte) error {
+	for i := 1; i < len(encoded); i++ {
+		b := encoded[i]
+		if !bytes.Equal(b, encoded[0]) {
+			return fmt.Errorf("%w: %q", ErrKeyConflict, key)
+		}
+	}
+	return nil
 }
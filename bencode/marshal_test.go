@@ -0,0 +1,205 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// upper 编码时转为小写，解码时转为大写，用于验证 Marshaler 和 Unmarshaler 被调用
+type upper string
+
+func (u upper) MarshalBencode() ([]byte, error) {
+	return Marshal(strings.ToLower(string(u)))
+}
+
+func (u *upper) UnmarshalBencode(data []byte) error {
+	var s string
+	err := Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	*u = upper(strings.ToUpper(s))
+	return nil
+}
+
+type upperHolder struct {
+	Field upper            `bencode:"field"`
+	Ptr   *upper           `bencode:"ptr"`
+	List  []upper          `bencode:"list"`
+	Map   map[string]upper `bencode:"map"`
+}
+
+func TestMarshaler(t *testing.T) {
+	p := upper("PTR")
+	h := upperHolder{
+		Field: "FIELD",
+		Ptr:   &p,
+		List:  []upper{"A"},
+		Map:   map[string]upper{"k": "V"},
+	}
+	data, err := Marshal(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("d5:field5:field4:listl1:ae3:mapd1:k1:ve3:ptr3:ptre")
+	if !bytes.Equal(data, want) {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
+
+func TestUnmarshaler(t *testing.T) {
+	var h upperHolder
+	err := Unmarshal([]byte("d5:field5:field4:listl1:ae3:mapd1:k1:ve3:ptr3:ptre"), &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Field != "FIELD" || h.Ptr == nil || *h.Ptr != "PTR" {
+		t.Fatalf("unexpected field %q %v", h.Field, h.Ptr)
+	}
+	if len(h.List) != 1 || h.List[0] != "A" {
+		t.Fatalf("unexpected list %v", h.List)
+	}
+	if h.Map["k"] != "V" {
+		t.Fatalf("unexpected map %v", h.Map)
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	m := map[string]int64{"zz": 1, "a": 2, "ab": 3, "B": 4}
+	want := []byte("d1:Bi4e1:ai2e2:abi3e2:zzi1ee")
+	for i := 0; i < 10; i++ {
+		data, err := Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Fatalf("got %q, want %q", data, want)
+		}
+	}
+
+	s := struct {
+		Z int64 `bencode:"z"`
+		A int64 `bencode:"a"`
+	}{1, 2}
+	data, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "d1:ai2e1:zi1ee" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestMarshalTagOptions(t *testing.T) {
+	type file struct {
+		Name    string   `bencode:"name"`
+		Comment string   `bencode:"comment,omitempty"`
+		Length  int64    `bencode:"length,omitempty"`
+		Path    []string `bencode:"path,omitempty"`
+	}
+
+	data, err := Marshal(file{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "d4:name0:e" {
+		t.Fatalf("unexpected output %q", data)
+	}
+
+	data, err = Marshal(file{Name: "a", Comment: "c", Length: 1, Path: []string{""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "d7:comment1:c6:lengthi1e4:name1:a4:pathl0:ee" {
+		t.Fatalf("unexpected output %q", data)
+	}
+
+	data, err = Marshal(map[string]string{"a": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "d1:a0:e" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestMarshalTypes(t *testing.T) {
+	v := struct {
+		U8    uint8    `bencode:"u8"`
+		U64   uint64   `bencode:"u64"`
+		True  bool     `bencode:"true"`
+		False bool     `bencode:"false"`
+		Bytes []byte   `bencode:"bytes"`
+		Hash  [4]byte  `bencode:"hash"`
+		Any   any      `bencode:"any"`
+		List  []any    `bencode:"list"`
+		I8    int8     `bencode:"i8"`
+		Strs  []string `bencode:"strs"`
+	}{
+		U8:    255,
+		U64:   1 << 63,
+		True:  true,
+		Bytes: []byte{0, 1},
+		Hash:  [4]byte{'a', 'b', 'c', 'd'},
+		Any:   "x",
+		List:  []any{int64(1), "a"},
+		I8:    -8,
+		Strs:  []string{},
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d3:any1:x5:bytes2:\x00\x015:falsei0e4:hash4:abcd2:i8i-8e4:listli1e1:ae4:strsle4:truei1e3:u64i9223372036854775808e2:u8i255ee"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+
+	_, err = Marshal(struct{ F float64 }{1})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("want ErrUnsupportedType, got %v", err)
+	}
+}
+
+// tree 递归类型，用于验证类型缓存可以处理自身引用的类型
+type tree struct {
+	Name     string  `bencode:"name"`
+	Children []tree  `bencode:"children,omitempty"`
+	Parent   *tree   `bencode:"-"`
+	Next     []*tree `bencode:"next,omitempty"`
+}
+
+func TestMarshalRecursiveType(t *testing.T) {
+	v := tree{Name: "a", Children: []tree{{Name: "b"}, {Name: "c", Next: []*tree{{Name: "d"}}}}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := Marshal(v)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			want := "d8:childrenld4:name1:bed4:name1:c4:nextld4:name1:deeee4:name1:ae"
+			if string(data) != want {
+				t.Errorf("got %q, want %q", data, want)
+			}
+
+			var got tree
+			err = Unmarshal(data, &got)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got.Children[1].Next[0].Name != "d" {
+				t.Errorf("got %+v", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
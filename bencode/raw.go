@@ -0,0 +1,32 @@
+package bencode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RawMessage 保存未解析的原始 bencode 数据
+// 反序列化时保存该值在输入中的原始数据，序列化时原样输出
+type RawMessage []byte
+
+// MarshalBencode 实现 Marshaler，原样返回 m，m 不是一个完整的 bencode 值时返回错误
+// 只检查语法，不规范的数据（例如未排序的 key）同样原样输出
+func (m RawMessage) MarshalBencode() ([]byte, error) {
+	if len(m) == 0 {
+		return nil, errors.New("bencode: empty RawMessage")
+	}
+	err := checkRaw(m)
+	if err != nil {
+		return nil, fmt.Errorf("bencode: invalid RawMessage: %w", err)
+	}
+	return m, nil
+}
+
+// UnmarshalBencode 实现 Unmarshaler，保存 data 的副本
+func (m *RawMessage) UnmarshalBencode(data []byte) error {
+	if m == nil {
+		return errors.New("bencode: UnmarshalBencode on nil pointer")
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
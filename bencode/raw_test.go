@@ -0,0 +1,65 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRawMessage(t *testing.T) {
+	data := []byte("d4:infod6:lengthi10e4:name1:ae4:name3:fooe")
+	var v struct {
+		InfoRaw RawMessage `bencode:"info"`
+		Info    struct {
+			Length int64  `bencode:"length"`
+			Name   string `bencode:"name"`
+		} `bencode:"info"`
+		Name string `bencode:"name"`
+	}
+	err := Unmarshal(data, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v.InfoRaw, []byte("d6:lengthi10e4:name1:ae")) {
+		t.Fatalf("unexpected raw %q", v.InfoRaw)
+	}
+	if v.Info.Length != 10 || v.Info.Name != "a" || v.Name != "foo" {
+		t.Fatalf("unexpected value %+v", v)
+	}
+
+	out, err := Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("got %q, want %q", out, data)
+	}
+
+	// 修改解析后的值但没有清空 RawMessage 时不会静默丢弃修改
+	v.Info.Name = "b"
+	_, err = Marshal(&v)
+	if !errors.Is(err, ErrKeyConflict) {
+		t.Fatalf("got %v, want ErrKeyConflict", err)
+	}
+	v.InfoRaw = nil
+	out, err = Marshal(&v)
+	if err != nil || string(out) != "d4:infod6:lengthi10e4:name1:be4:name3:fooe" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
+
+func TestRawMessageInvalid(t *testing.T) {
+	for _, raw := range []string{"i1", "4:ab", "i1ei2e", "x", "d1:ae"} {
+		_, err := Marshal(map[string]RawMessage{"k": RawMessage(raw)})
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%q: got %v, want *SyntaxError", raw, err)
+		}
+	}
+
+	// 只检查语法，不规范的数据原样输出
+	out, err := Marshal(RawMessage("d1:bi1e1:ai01ee"))
+	if err != nil || string(out) != "d1:bi1e1:ai01ee" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
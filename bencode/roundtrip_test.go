@@ -0,0 +1,175 @@
+package bencode
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+// PropBase 嵌入到 propValue 中，验证嵌入结构体的字段提升
+type PropBase struct {
+	ID   uint32 `bencode:"id"`
+	Name string `bencode:"name"`
+}
+
+type propNested struct {
+	Path  []string `bencode:"path"`
+	Flags []bool   `bencode:"flags"`
+}
+
+// propValue 包含 Marshal 和 Unmarshal 支持的各种类型
+type propValue struct {
+	PropBase
+	I     int                    `bencode:"i"`
+	I8    int8                   `bencode:"i8"`
+	I16   int16                  `bencode:"i16"`
+	I64   int64                  `bencode:"i64"`
+	U     uint                   `bencode:"u"`
+	U8    uint8                  `bencode:"u8"`
+	U64   uint64                 `bencode:"u64"`
+	B     bool                   `bencode:"b"`
+	S     string                 `bencode:"s"`
+	Bytes []byte                 `bencode:"bytes"`
+	Hash  [20]byte               `bencode:"hash"`
+	List  []int32                `bencode:"list"`
+	Map   map[string]uint16      `bencode:"map"`
+	Files []propNested           `bencode:"files"`
+	Index map[string]propNested  `bencode:"index"`
+	Ptr   *string                `bencode:"ptr"`
+	Inner *propNested            `bencode:"inner"`
+	Grid  [][2]int64             `bencode:"grid"`
+	Opt   string                 `bencode:"opt,omitempty"`
+	Deep  map[string][]*PropBase `bencode:"deep"`
+}
+
+func TestRoundTripProperty(t *testing.T) {
+	f := func(v propValue) bool {
+		data, err := Marshal(v)
+		if err != nil {
+			t.Logf("Marshal: %v", err)
+			return false
+		}
+		if err := Valid(data); err != nil {
+			t.Logf("Marshal produced non-canonical data: %v", err)
+			return false
+		}
+
+		var got propValue
+		err = Unmarshal(data, &got)
+		if err != nil {
+			t.Logf("Unmarshal: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(normalize(v), got) {
+			t.Logf("got  %+v\nwant %+v", got, v)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// normalize 返回 v 经过编码和解码之后应有的值：nil 元素编码为零值
+func normalize(v propValue) propValue {
+	for _, list := range v.Deep {
+		for i, p := range list {
+			if p == nil {
+				list[i] = &PropBase{}
+			}
+		}
+	}
+	return v
+}
+
+// randomAny 生成一个深度不超过 depth 的随机值，类型与 Unmarshal 到 any 时的结果相同
+func randomAny(r *rand.Rand, depth int) any {
+	kind := r.Intn(4)
+	if depth == 0 {
+		kind = r.Intn(2)
+	}
+	switch kind {
+	case 0:
+		return r.Int63() - r.Int63()
+	case 1:
+		b := make([]byte, r.Intn(32))
+		r.Read(b)
+		return string(b)
+	case 2:
+		list := []any{}
+		for i := r.Intn(5); i > 0; i-- {
+			list = append(list, randomAny(r, depth-1))
+		}
+		return list
+	default:
+		dict := map[string]any{}
+		for i := r.Intn(5); i > 0; i-- {
+			dict["k"+strconv.Itoa(r.Intn(100))] = randomAny(r, depth-1)
+		}
+		return dict
+	}
+}
+
+func TestRoundTripAny(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		v := randomAny(r, 5)
+		data, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got any
+		err = Unmarshal(data, &got)
+		if err != nil {
+			t.Fatalf("%q: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("got %#v, want %#v", got, v)
+		}
+
+		// Value 和 JSON 的转换也不应该改变数据
+		val, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := val.Encode()
+		if err != nil || string(enc) != string(data) {
+			t.Fatalf("Value.Encode: got %q, %v, want %q", enc, err, data)
+		}
+		js, err := ToJSON(data, JSONOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := FromJSON(js)
+		if err != nil || string(back) != string(data) {
+			t.Fatalf("FromJSON(%s): got %q, %v, want %q", js, back, err, data)
+		}
+	}
+}
+
+func TestRoundTripIntBounds(t *testing.T) {
+	type ints struct {
+		MinI64 int64  `bencode:"a"`
+		MaxI64 int64  `bencode:"b"`
+		MaxU64 uint64 `bencode:"c"`
+		MaxU32 uint32 `bencode:"d"`
+		MinI8  int8   `bencode:"e"`
+	}
+	v := ints{-1 << 63, 1<<63 - 1, 1<<64 - 1, 1<<32 - 1, -128}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ints
+	err = Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != v {
+		t.Fatalf("got %+v, want %+v", got, v)
+	}
+}
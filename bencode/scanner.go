@@ -0,0 +1,436 @@
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// TokenKind 词法单元的类型
+type TokenKind uint8
+
+const (
+	TokenInt    TokenKind = iota + 1 // 整数
+	TokenString                      // 字符串，包括字典的 key
+	TokenList                        // 列表开始
+	TokenDict                        // 字典开始
+	TokenEnd                         // 列表或字典结束
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenInt:
+		return "int"
+	case TokenString:
+		return "string"
+	case TokenList:
+		return "list"
+	case TokenDict:
+		return "dict"
+	case TokenEnd:
+		return "end"
+	default:
+		return "none"
+	}
+}
+
+// Token 词法单元
+type Token struct {
+	Kind TokenKind
+	// TokenInt 为 i 和 e 之间的数字，TokenString 为字符串的内容（不包含长度前缀）
+	// Value 引用传给 NewScanner 的数据，不会复制
+	Value  []byte
+	Offset int64 // 在输入中的偏移量
+}
+
+// Int 返回 TokenInt 的值，超出 int64 范围时返回 ErrInt
+func (t Token) Int() (int64, error) {
+	negative, u, err := t.magnitude()
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		if u > 1<<63 {
+			return 0, fmt.Errorf("%w: %s overflows int64", ErrInt, t.Value)
+		}
+		return -int64(u), nil
+	}
+	if u > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %s overflows int64", ErrInt, t.Value)
+	}
+	return int64(u), nil
+}
+
+// Uint 返回 TokenInt 的值，负数时返回 ErrInt
+func (t Token) Uint() (uint64, error) {
+	negative, u, err := t.magnitude()
+	if err != nil {
+		return 0, err
+	}
+	if negative && u != 0 {
+		return 0, fmt.Errorf("%w: %s is negative", ErrInt, t.Value)
+	}
+	return u, nil
+}
+
+// magnitude 返回 TokenInt 的符号和绝对值，Scanner 已经检查过格式和范围
+func (t Token) magnitude() (bool, uint64, error) {
+	if t.Kind != TokenInt {
+		return false, 0, ErrInt
+	}
+	v := t.Value
+	negative := len(v) > 0 && v[0] == '-'
+	if negative {
+		v = v[1:]
+	}
+	var res uint64
+	for _, b := range v {
+		if b < '0' || b > '9' || res > (math.MaxUint64-uint64(b-'0'))/10 {
+			return false, 0, fmt.Errorf("%w: invalid integer %q", ErrInt, t.Value)
+		}
+		res = res*10 + uint64(b-'0')
+	}
+	return negative, res, nil
+}
+
+// Scanner 将 bencode 数据拆分为词法单元，返回的 Token 直接引用输入数据，不会分配内存
+// Scanner 检查数据的语法，例如字典的 key 必须是字符串、列表和字典必须闭合
+type Scanner struct {
+	data   []byte
+	off    int
+	end    int   // 允许读取的数据末尾，受 MaxInputSize 限制
+	base   int64 // 错误信息中偏移量的基准
+	strict bool
+	limits Limits
+
+	elements int
+	stack    []scanFrame
+	err      error
+}
+
+// scanFrame 记录正在扫描的列表或字典的状态
+type scanFrame struct {
+	dict bool
+	key  bool     // 字典中下一个 token 应该是 key
+	prev []byte   // 字典中上一个 key
+	keys [][]byte // 非严格模式下字典中出现过的 key，用于检查重复
+	seen map[string]struct{}
+}
+
+// NewScanner 创建一个扫描 data 的 Scanner，默认使用 DefaultLimits
+func NewScanner(data []byte) *Scanner {
+	s := &Scanner{data: data}
+	s.SetLimits(DefaultLimits)
+	return s
+}
+
+// Strict 开启严格模式，拒绝不符合 BEP 3 规范的数据，规则与 Decoder.Strict 相同
+func (s *Scanner) Strict() {
+	s.strict = true
+}
+
+// SetLimits 设置扫描时的资源限制
+func (s *Scanner) SetLimits(l Limits) {
+	s.limits = l
+	s.end = len(s.data)
+	if l.MaxInputSize > 0 && int64(s.end) > l.MaxInputSize {
+		s.end = int(l.MaxInputSize)
+	}
+}
+
+// Offset 返回已扫描的字节数
+func (s *Scanner) Offset() int64 {
+	return int64(s.off)
+}
+
+// Depth 返回当前所在的列表和字典的嵌套深度
+func (s *Scanner) Depth() int {
+	return len(s.stack)
+}
+
+// Next 返回下一个词法单元，所有数据扫描完毕时返回 io.EOF
+// 数据有误时返回 *SyntaxError 或 *LimitError，之后的调用都会返回同一个错误
+func (s *Scanner) Next() (Token, error) {
+	if s.err != nil {
+		return Token{}, s.err
+	}
+	tok, err := s.next()
+	if err != nil {
+		s.err = err
+	}
+	return tok, err
+}
+
+func (s *Scanner) next() (Token, error) {
+	if s.off >= s.end {
+		if len(s.stack) == 0 && s.end == len(s.data) {
+			return Token{}, io.EOF
+		}
+		return Token{}, s.eof()
+	}
+
+	var frame *scanFrame
+	if n := len(s.stack); n > 0 {
+		frame = &s.stack[n-1]
+	}
+
+	c := s.data[s.off]
+	if frame != nil && frame.dict && frame.key {
+		if c == 'e' {
+			return s.pop(), nil
+		}
+		tok, err := s.scanString()
+		if err != nil {
+			return tok, err
+		}
+		err = s.checkKey(frame, tok)
+		if err != nil {
+			return tok, err
+		}
+		frame.key = false
+		return tok, nil
+	}
+
+	if c == 'e' {
+		if frame == nil {
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("%w %q", ErrUnknowByte, c))
+		}
+		if frame.dict {
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("dict has key %q but no value", frame.prev))
+		}
+		return s.pop(), nil
+	}
+
+	s.elements++
+	if s.limits.MaxElements > 0 && s.elements > s.limits.MaxElements {
+		return Token{}, s.limitError("MaxElements", int64(s.limits.MaxElements))
+	}
+	if frame != nil && frame.dict {
+		frame.key = true
+	}
+
+	switch c {
+	case 'i':
+		return s.scanInt()
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return s.scanString()
+	case 'l', 'd':
+		return s.push(c == 'd')
+	default:
+		return Token{}, s.syntaxError(s.off, fmt.Errorf("%w %q", ErrUnknowByte, c))
+	}
+}
+
+// skip 跳过 tok 开始的值的剩余部分
+func (s *Scanner) skip(tok Token) error {
+	if tok.Kind != TokenList && tok.Kind != TokenDict {
+		return nil
+	}
+	depth := len(s.stack) - 1
+	for len(s.stack) > depth {
+		_, err := s.Next()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Skip 跳过 tok 开始的值，返回该值的原始数据，tok 必须是上一次调用 Next 的返回值
+func (s *Scanner) Skip(tok Token) ([]byte, error) {
+	start := tok.Offset - s.base
+	err := s.skip(tok)
+	if err != nil {
+		return nil, err
+	}
+	return s.data[start:s.off], nil
+}
+
+// Sub 返回一个扫描 raw 的 Scanner，raw 为 Skip 返回的 tok 开始的值的原始数据
+// 新的 Scanner 使用与 s 相同的模式和限制，错误信息中的偏移量与 s 一致
+func (s *Scanner) Sub(tok Token, raw []byte) *Scanner {
+	sub := NewScanner(raw)
+	sub.base = tok.Offset
+	sub.strict = s.strict
+	sub.SetLimits(s.limits)
+	return sub
+}
+
+func (s *Scanner) push(dict bool) (Token, error) {
+	tok := Token{Kind: TokenList, Offset: s.base + int64(s.off)}
+	if dict {
+		tok.Kind = TokenDict
+	}
+	s.off++
+
+	if s.limits.MaxDepth > 0 && len(s.stack) >= s.limits.MaxDepth {
+		return tok, s.limitError("MaxDepth", int64(s.limits.MaxDepth))
+	}
+	// 复用 stack 中已有的 frame，避免重复分配 keys
+	if len(s.stack) < cap(s.stack) {
+		s.stack = s.stack[:len(s.stack)+1]
+		frame := &s.stack[len(s.stack)-1]
+		*frame = scanFrame{dict: dict, key: dict, keys: frame.keys[:0]}
+	} else {
+		s.stack = append(s.stack, scanFrame{dict: dict, key: dict})
+	}
+	return tok, nil
+}
+
+func (s *Scanner) pop() Token {
+	tok := Token{Kind: TokenEnd, Offset: s.base + int64(s.off)}
+	s.off++
+	s.stack = s.stack[:len(s.stack)-1]
+	return tok
+}
+
+// checkKey 检查字典的 key 是否重复，严格模式下还要求 key 按原始字节升序排列
+func (s *Scanner) checkKey(frame *scanFrame, tok Token) error {
+	key := tok.Value
+	prev, first := frame.prev, frame.prev == nil
+	frame.prev = key
+
+	if frame.seen != nil {
+		return s.checkSeen(frame, key)
+	}
+	// key 按顺序排列时不可能重复
+	if first || bytes.Compare(key, prev) > 0 {
+		if !s.strict {
+			frame.keys = append(frame.keys, key)
+		}
+		return nil
+	}
+	if bytes.Equal(key, prev) {
+		return s.syntaxError(s.off, fmt.Errorf("duplicate key: %q", key))
+	}
+	if s.strict {
+		return s.syntaxError(s.off, fmt.Errorf("%w: %q after %q", ErrUnsorted, key, prev))
+	}
+
+	// 非严格模式下允许乱序，此时需要和之前所有的 key 比较
+	frame.seen = make(map[string]struct{}, len(frame.keys))
+	for _, k := range frame.keys {
+		frame.seen[string(k)] = struct{}{}
+	}
+	return s.checkSeen(frame, key)
+}
+
+// checkSeen 检查 key 是否在字典中出现过
+func (s *Scanner) checkSeen(frame *scanFrame, key []byte) error {
+	if _, ok := frame.seen[string(key)]; ok {
+		return s.syntaxError(s.off, fmt.Errorf("duplicate key: %q", key))
+	}
+	frame.seen[string(key)] = struct{}{}
+	return nil
+}
+
+func (s *Scanner) scanInt() (Token, error) {
+	start := s.off
+	s.off++ // 'i'
+
+	digits := 0
+	negative := false
+	leadingZero := false
+	// 正数可以达到 uint64 的范围，负数可以达到 int64 的范围
+	var res, max uint64 = 0, math.MaxUint64
+	for {
+		if s.off >= s.end {
+			return Token{}, s.eof()
+		}
+		b := s.data[s.off]
+		s.off++
+
+		switch b {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			if digits == 0 && b == '0' {
+				leadingZero = true
+			}
+			if res > (max-uint64(b-'0'))/10 {
+				return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: overflows 64-bit integer", ErrInt))
+			}
+			res = res*10 + uint64(b-'0')
+			digits++
+		case '-':
+			if digits != 0 || negative {
+				return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: unexpected '-'", ErrInt))
+			}
+			negative = true
+			max = 1 << 63
+		case 'e':
+			if digits == 0 {
+				return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: no digits", ErrInt))
+			}
+			// 严格模式下只允许规范的整数：不能有前导 0，不能是 -0
+			if s.strict && leadingZero && (digits > 1 || negative) {
+				return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: integer with leading zero or negative zero", ErrNonCanonical))
+			}
+			return Token{Kind: TokenInt, Value: s.data[start+1 : s.off-1], Offset: s.base + int64(start)}, nil
+		default:
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: unexpected byte %q", ErrInt, b))
+		}
+	}
+}
+
+func (s *Scanner) scanString() (Token, error) {
+	start := s.off
+	length := 0
+	digits := 0
+	for {
+		if s.off >= s.end {
+			return Token{}, s.eof()
+		}
+		b := s.data[s.off]
+		s.off++
+
+		if b == ':' {
+			break
+		}
+		if b < '0' || b > '9' {
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("%w, got %q", ErrColon, b))
+		}
+		if s.strict && digits == 1 && length == 0 {
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: string length with leading zero", ErrNonCanonical))
+		}
+		if length > (math.MaxInt-int(b-'0'))/10 {
+			return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: length overflows int", ErrStr))
+		}
+		length = length*10 + int(b-'0')
+		digits++
+	}
+	if digits == 0 {
+		return Token{}, s.syntaxError(s.off, fmt.Errorf("%w: missing length", ErrStr))
+	}
+	if s.limits.MaxStringLen > 0 && length > s.limits.MaxStringLen {
+		return Token{}, s.limitError("MaxStringLen", int64(s.limits.MaxStringLen))
+	}
+	if length > s.end-s.off {
+		if s.end < len(s.data) {
+			return Token{}, s.limitError("MaxInputSize", s.limits.MaxInputSize)
+		}
+		s.off = s.end
+		return Token{}, s.eof()
+	}
+
+	tok := Token{Kind: TokenString, Value: s.data[s.off : s.off+length], Offset: s.base + int64(start)}
+	s.off += length
+	return tok, nil
+}
+
+// eof 返回数据意外结束的错误，如果是因为 MaxInputSize 限制而结束，返回 *LimitError
+func (s *Scanner) eof() error {
+	if s.end < len(s.data) {
+		return s.limitError("MaxInputSize", s.limits.MaxInputSize)
+	}
+	return s.syntaxError(s.end, io.ErrUnexpectedEOF)
+}
+
+// syntaxError 返回读取了 off 个字节之后发现的语法错误
+func (s *Scanner) syntaxError(off int, err error) error {
+	return &SyntaxError{Offset: s.base + int64(off), Msg: err.Error(), err: err}
+}
+
+// limitError 返回当前位置超出限制的错误
+func (s *Scanner) limitError(limit string, max int64) error {
+	return &LimitError{Limit: limit, Max: max, Offset: s.base + int64(s.off)}
+}
@@ -0,0 +1,84 @@
+package bencode
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	data := []byte("d3:bari-12e3:fool4:spamdee1:xi0ee")
+	want := []struct {
+		kind   TokenKind
+		value  string
+		offset int64
+	}{
+		{TokenDict, "", 0},
+		{TokenString, "bar", 1},
+		{TokenInt, "-12", 6},
+		{TokenString, "foo", 11},
+		{TokenList, "", 16},
+		{TokenString, "spam", 17},
+		{TokenDict, "", 23},
+		{TokenEnd, "", 24},
+		{TokenEnd, "", 25},
+		{TokenString, "x", 26},
+		{TokenInt, "0", 29},
+		{TokenEnd, "", 32},
+	}
+
+	s := NewScanner(data)
+	for i, w := range want {
+		tok, err := s.Next()
+		if err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+		if tok.Kind != w.kind || string(tok.Value) != w.value || tok.Offset != w.offset {
+			t.Errorf("token %d: got %s %q at %d, want %s %q at %d", i, tok.Kind, tok.Value, tok.Offset, w.kind, w.value, w.offset)
+		}
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	cases := []struct {
+		data   string
+		strict bool
+		err    error
+		offset int64
+	}{
+		{"li1e", false, io.ErrUnexpectedEOF, 4},
+		{"di1ei2ee", false, ErrColon, 2},
+		{"d1:ai1e1:ai2ee", false, nil, 10},
+		{"d1:bi1e1:ai2ee", true, ErrUnsorted, 10},
+		{"i03e", true, ErrNonCanonical, 4},
+		{"e", false, ErrUnknowByte, 0},
+	}
+
+	for _, c := range cases {
+		s := NewScanner([]byte(c.data))
+		if c.strict {
+			s.Strict()
+		}
+		var err error
+		for err == nil {
+			_, err = s.Next()
+		}
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%q: got %v, want *SyntaxError", c.data, err)
+			continue
+		}
+		if c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%q: got %v, want %v", c.data, err, c.err)
+		}
+		if se.Offset != c.offset {
+			t.Errorf("%q: got offset %d, want %d", c.data, se.Offset, c.offset)
+		}
+		if _, again := s.Next(); again != err {
+			t.Errorf("%q: error not sticky: %v", c.data, again)
+		}
+	}
+}
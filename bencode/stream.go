@@ -0,0 +1,121 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+)
+
+// Decoder 从输入流中依次读取并解析 bencode 数据
+type Decoder struct {
+	r      *peekReader
+	offset int64 // 已解析的字节数
+	strict bool
+	limits Limits
+}
+
+// NewDecoder 创建一个从 r 读取数据的 Decoder
+// Decoder 不会预读，每次 Decode 之后 r 正好停在该值之后，调用者可以继续从 r 读取其他数据
+// r 实现了 io.ByteReader 时逐字节读取，否则每次只 Read 一个字节，对于文件、网络连接等
+// 可以传入 *bufio.Reader 减少系统调用，之后从同一个 *bufio.Reader 继续读取
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: newPeekReader(r), limits: DefaultLimits}
+}
+
+// Decode 从输入流中读取下一个完整的值并保存到 v 指向的变量中
+// 输入流在值的边界处结束时返回 io.EOF
+func (d *Decoder) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrNotPtr
+	}
+
+	// 值的开头就遇到 EOF 说明输入流正常结束，值的中间遇到 EOF 说明数据被截断
+	if _, err := d.r.peek(); err != nil {
+		return err
+	}
+	// 先按照语法从流中读取一个完整的值，再交给 Scanner 解码，不会读取超出该值的数据
+	r := &scanReader{peekReader: d.r, limits: d.limits}
+	buf := bytes.NewBuffer(nil)
+	err := scans(r, buf)
+	data := buf.Bytes()
+	if err != nil {
+		return d.scanError(r, data, err)
+	}
+
+	s := d.scanner(data)
+	d.offset += int64(len(data))
+	return newDecodeState(s).unmarshal(rv)
+}
+
+// scanner 返回解码 data 的 Scanner，data 是输入流中从 d.offset 开始的数据
+func (d *Decoder) scanner(data []byte) *Scanner {
+	s := NewScanner(data)
+	s.base = d.offset
+	s.strict = d.strict
+	s.SetLimits(d.limits)
+	return s
+}
+
+// scanError 转换读取值时遇到的错误，data 为出错之前读取的数据
+// Scanner 的检查比 scans 更严格，优先返回 Scanner 在 data 中发现的错误
+func (d *Decoder) scanError(r *scanReader, data []byte, err error) error {
+	defer func() { d.offset += r.off }()
+
+	s := d.scanner(data)
+	serr := newDecodeState(s).skipValue()
+	if serr != nil && !errors.Is(serr, io.ErrUnexpectedEOF) {
+		return serr
+	}
+
+	err = scanError(r, err)
+	switch e := err.(type) {
+	case *LimitError:
+		e.Offset += d.offset
+	case *SyntaxError:
+		e.Offset += d.offset
+	}
+	return err
+}
+
+// Strict 开启严格模式，解码时拒绝不符合 BEP 3 规范的数据：
+// 带有前导 0 的整数和字符串长度、-0、未按顺序排列的 key，用于检测不规范的输入
+func (d *Decoder) Strict() {
+	d.strict = true
+}
+
+// SetLimits 设置解码时的资源限制，默认使用 DefaultLimits，每次 Decode 单独计算
+func (d *Decoder) SetLimits(l Limits) {
+	d.limits = l
+}
+
+// Buffered 返回 Decoder 已经从输入流读取但尚未解析的数据
+// Decoder 不会预读，只有 Decode 出错时可能剩余一个字节，成功时总是为空
+func (d *Decoder) Buffered() io.Reader {
+	if !d.r.peeked {
+		return bytes.NewReader(nil)
+	}
+	return bytes.NewReader([]byte{d.r.buf[0]})
+}
+
+// Encoder 将 bencode 数据写入输出流
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder 创建一个写入 w 的 Encoder
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode 将 v 编码后写入输出流，每次调用写入一个完整的值
+func (e *Encoder) Encode(v any) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
@@ -0,0 +1,109 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	r := strings.NewReader("i42e4:spamli1ei2eed3:cow3:mooe")
+	dec := NewDecoder(r)
+
+	var i int64
+	if err := dec.Decode(&i); err != nil || i != 42 {
+		t.Fatalf("decode int: %v %d", err, i)
+	}
+	var s string
+	if err := dec.Decode(&s); err != nil || s != "spam" {
+		t.Fatalf("decode string: %v %q", err, s)
+	}
+	var l []int64
+	if err := dec.Decode(&l); err != nil || len(l) != 2 || l[0] != 1 || l[1] != 2 {
+		t.Fatalf("decode list: %v %v", err, l)
+	}
+	var d struct {
+		Cow string `bencode:"cow"`
+	}
+	if err := dec.Decode(&d); err != nil || d.Cow != "moo" {
+		t.Fatalf("decode dict: %v %v", err, d)
+	}
+	if err := dec.Decode(&s); err != io.EOF {
+		t.Fatalf("want io.EOF, got %v", err)
+	}
+}
+
+func TestDecoderPosition(t *testing.T) {
+	// Decoder 不预读，每次 Decode 之后底层的 Reader 停在值之后
+	r := strings.NewReader("i1eli2eeREST")
+	dec := NewDecoder(r)
+	var i int
+	if err := dec.Decode(&i); err != nil || i != 1 || r.Len() != 9 {
+		t.Fatalf("first: %v %d, remaining %d", err, i, r.Len())
+	}
+	var l []int
+	if err := dec.Decode(&l); err != nil || len(l) != 1 || r.Len() != 4 {
+		t.Fatalf("second: %v %v, remaining %d", err, l, r.Len())
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "REST" {
+		t.Fatalf("rest = %q", rest)
+	}
+
+	// 没有实现 io.ByteReader 的 Reader 同样不会被多读
+	src := strings.NewReader("4:spami2eREST")
+	dec = NewDecoder(struct{ io.Reader }{src})
+	var s string
+	if err := dec.Decode(&s); err != nil || s != "spam" || src.Len() != 7 {
+		t.Fatalf("string: %v %q, remaining %d", err, s, src.Len())
+	}
+	if err := dec.Decode(&i); err != nil || i != 2 || src.Len() != 4 {
+		t.Fatalf("int: %v %d, remaining %d", err, i, src.Len())
+	}
+	if n, _ := dec.Buffered().Read(make([]byte, 1)); n != 0 {
+		t.Fatalf("Buffered returned %d bytes", n)
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("li1ei2e"))
+	var l []int64
+	if err := dec.Decode(&l); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("want io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(buf)
+	if err := enc.Encode(42); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "i42el1:a1:be" {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	var m map[string]int64
+	err := NewDecoder(strings.NewReader("d1:bi1e1:ai2ee")).Decode(&m)
+	if err != nil {
+		t.Fatalf("non-strict decoder should accept unsorted keys: %v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader("d1:bi1e1:ai2ee"))
+	dec.Strict()
+	if err := dec.Decode(&m); !errors.Is(err, ErrUnsorted) {
+		t.Fatalf("want ErrUnsorted, got %v", err)
+	}
+
+	dec = NewDecoder(strings.NewReader("d1:ai2e1:bi1ee"))
+	dec.Strict()
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,156 +1,617 @@
 package bencode
 
 import (
-	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
-	"unicode"
 )
 
-// TODO:
-// 在所有 unmarshal 中应该是先创建一个和目标数据类型一致的 reflect.Value
-// 然后把这个值 Set 到目标变量上
+var (
+	ErrNotPtr     = errors.New("not a pointer or nil")
+	ErrMissingKey = errors.New("missing required key")
+)
 
-var ErrNotPtr = errors.New("not a pointer or nil")
+// Unmarshaler 由可以自行解析 bencode 数据的类型实现
+// data 为该值在输入中的原始数据，如果需要在返回后继续使用，需要自行复制
+type Unmarshaler interface {
+	UnmarshalBencode(data []byte) error
+}
 
-// TODO: 类型不匹配的时候不要直接 panic，而是返回一个 error
+// ScannerUnmarshaler 由可以直接从 Scanner 中读取值的类型实现，例如 cmd/bencodegen 生成的类型
+// tok 为该值的第一个词法单元，返回时 s 需要位于该值之后；解码可以取地址的值时优先于 Unmarshaler 使用，
+// 不需要先扫描整个值，错误信息中的偏移量也与外层一致
+type ScannerUnmarshaler interface {
+	UnmarshalBencodeFrom(s *Scanner, tok Token) error
+}
+
+// Unmarshal 解析 bencode 数据并保存到 res 指向的变量中
+// 整数可以保存到任意宽度的整数（超出范围时返回错误）和 bool，字符串可以保存到 string、[]byte 和 [N]byte，
+// 保存到 interface{} 时分别使用 int64、string、[]any 和 map[string]any
+// 值之后多余的数据被忽略，需要严格检查时使用 DecodeOptions{Strict: true}.Unmarshal
 func Unmarshal(data []byte, res any) error {
+	return DecodeOptions{}.Unmarshal(data, res)
+}
+
+// DecodeOptions 解码内存中的数据时的选项，零值与 Unmarshal 的行为相同
+type DecodeOptions struct {
+	// Strict 开启严格模式，规则与 Decoder.Strict 相同，Unmarshal 同时拒绝值之后多余的数据
+	Strict bool
+	// Limits 资源限制，为 nil 时使用 DefaultLimits，&Limits{} 表示不限制
+	Limits *Limits
+}
+
+// scanner 创建一个按照 o 扫描 data 的 Scanner
+func (o DecodeOptions) scanner(data []byte) *Scanner {
+	s := NewScanner(data)
+	if o.Strict {
+		s.Strict()
+	}
+	if o.Limits != nil {
+		s.SetLimits(*o.Limits)
+	}
+	return s
+}
+
+// Unmarshal 与包级别的 Unmarshal 相同，严格模式下数据必须恰好是一个规范的值，
+// 相当于 Valid 和 Unmarshal 的组合，但只解析一次
+func (o DecodeOptions) Unmarshal(data []byte, res any) error {
 	rv := reflect.ValueOf(res)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
 		return ErrNotPtr
 	}
 
-	reader := bytes.NewReader(data)
-	bo, err := parser(reader)
+	s := o.scanner(data)
+	err := newDecodeState(s).unmarshal(rv)
+	if err != nil || !o.Strict {
+		return err
+	}
+	return trailing(s)
+}
+
+// DecodePrefix 解析 data 开头的一个完整的值并保存到 v 指向的变量中，返回该值占用的字节数
+// 用于处理 bencode 字典之后紧跟原始数据的消息，例如 ut_metadata 的 data 消息，data[n:] 即为之后的数据，不会被复制
+// 只要开头的值语法正确，即使保存到 v 时出错（例如 *UnmarshalTypeError），n 也是该值的长度；语法错误时 n 为 0
+func DecodePrefix(data []byte, v any) (n int, err error) {
+	return DecodeOptions{}.DecodePrefix(data, v)
+}
+
+// DecodePrefix 与包级别的 DecodePrefix 相同，严格模式下不检查值之后的数据
+func (o DecodeOptions) DecodePrefix(data []byte, v any) (n int, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return 0, ErrNotPtr
+	}
+
+	s := o.scanner(data)
+	d := newDecodeState(s)
+	tok, err := d.next()
+	if err != nil {
+		return 0, err
+	}
+	err = d.value(tok, rv)
+	if err == nil {
+		return s.off, nil
+	}
+
+	// 保存到 v 时出错，跳过该值剩余的部分得到它的长度
+	if s.err != nil {
+		return 0, err
+	}
+	for s.Depth() > 0 {
+		_, serr := d.next()
+		if serr != nil {
+			return 0, serr
+		}
+	}
+	return s.off, err
+}
+
+// decodeState 从 Scanner 中读取词法单元，直接反序列化到目标变量，不构建中间结构
+type decodeState struct {
+	s *Scanner
+}
+
+func newDecodeState(s *Scanner) *decodeState {
+	return &decodeState{s: s}
+}
+
+// unmarshal 读取一个完整的值并保存到 rv 指向的变量中
+func (d *decodeState) unmarshal(rv reflect.Value) error {
+	tok, err := d.next()
 	if err != nil {
 		return err
 	}
+	return d.value(tok, rv)
+}
 
-	switch bo._type {
-	case BenInt, BenStr:
-		return set(rv, bo._value)
+// skipValue 跳过一个完整的值
+func (d *decodeState) skipValue() error {
+	tok, err := d.next()
+	if err != nil {
+		return err
+	}
+	return d.s.skip(tok)
+}
 
-	case BenLst:
-		return unmarshalList(bo._value.([]benObject), rv.Elem())
+// next 读取下一个词法单元，在值的中间遇到数据结束时返回 io.ErrUnexpectedEOF
+func (d *decodeState) next() (Token, error) {
+	tok, err := d.s.Next()
+	if err == io.EOF {
+		return tok, d.s.syntaxError(d.s.off, io.ErrUnexpectedEOF)
+	}
+	return tok, err
+}
+
+// raw 跳过 tok 开始的值，返回该值的原始数据
+func (d *decodeState) raw(tok Token) ([]byte, error) {
+	return d.s.Skip(tok)
+}
+
+// value 将 tok 开始的值反序列化到 rv
+func (d *decodeState) value(tok Token, rv reflect.Value) error {
+	return typeDecoder(rv.Type())(d, tok, rv)
+}
+
+// decoderFunc 将 tok 开始的值反序列化到 v，每种类型的解码函数只构造一次
+type decoderFunc func(d *decodeState, tok Token, v reflect.Value) error
+
+var (
+	unmarshalerType        = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	scannerUnmarshalerType = reflect.TypeOf((*ScannerUnmarshaler)(nil)).Elem()
+)
+
+// newTypeDecoder 构造类型 t 的解码函数
+func newTypeDecoder(t reflect.Type) decoderFunc {
+	if t.Kind() == reflect.Pointer {
+		return newKindDecoder(t)
+	}
 
-	case BenDir:
-		return unmarshalDir(bo._value.(map[string]benObject), rv.Elem())
+	dec := newKindDecoder(t)
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		dec = condAddrDecoder(addrUnmarshalerDecoder, dec)
+	}
+	if reflect.PointerTo(t).Implements(scannerUnmarshalerType) {
+		dec = condAddrDecoder(addrScannerUnmarshalerDecoder, dec)
+	}
+	return dec
+}
+
+// newKindDecoder 根据 t 的 Kind 构造解码函数
+func newKindDecoder(t reflect.Type) decoderFunc {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Bool:
+		return intDecoder
+
+	case reflect.String:
+		return stringDecoder
+
+	case reflect.Slice:
+		return newSliceDecoder(t)
+
+	case reflect.Array:
+		return newArrayDecoder(t)
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return typeErrorDecoder
+		}
+		return newMapDecoder(t)
+
+	case reflect.Struct:
+		return newStructDecoder(t)
+
+	case reflect.Interface:
+		return interfaceDecoder
+
+	case reflect.Pointer:
+		return newPtrDecoder(t)
 
 	default:
-		return errors.New("unknown type")
+		return typeErrorDecoder
+	}
+}
+
+// unmarshalerDecoder 将值的原始数据交给 Unmarshaler
+func unmarshalerDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	raw, err := d.raw(tok)
+	if err != nil {
+		return err
 	}
+	return v.Interface().(Unmarshaler).UnmarshalBencode(raw)
+}
 
+// scannerUnmarshalerDecoder 交给 ScannerUnmarshaler 从同一个 Scanner 中读取值
+func scannerUnmarshalerDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	return v.Interface().(ScannerUnmarshaler).UnmarshalBencodeFrom(d.s, tok)
 }
 
-// unmarshalList 反序列化列表类型的 BenObject
-func unmarshalList(bens []benObject, ref reflect.Value) error {
-	refEl := elem(ref)
-	typ := refEl.Type()
-	if refEl.Kind() == reflect.Slice || refEl.Kind() == reflect.Array {
-		typ = refEl.Type().Elem()
+// addrScannerUnmarshalerDecoder 解码指针类型实现了 ScannerUnmarshaler 的值，v 必须可以取地址
+func addrScannerUnmarshalerDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	return scannerUnmarshalerDecoder(d, tok, v.Addr())
+}
+
+// addrUnmarshalerDecoder 解码指针类型实现了 Unmarshaler 的值，v 必须可以取地址
+func addrUnmarshalerDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	return unmarshalerDecoder(d, tok, v.Addr())
+}
+
+// condAddrDecoder v 可以取地址时使用 addr，否则使用 other
+func condAddrDecoder(addr, other decoderFunc) decoderFunc {
+	return func(d *decodeState, tok Token, v reflect.Value) error {
+		if v.CanAddr() {
+			return addr(d, tok, v)
+		}
+		return other(d, tok, v)
 	}
+}
 
-	newSlice := reflect.MakeSlice(reflect.SliceOf(typ), len(bens), len(bens))
-	var err error
-	for i, v := range bens {
-		el := reflect.New(typ)
-		switch v._type {
-		case BenInt, BenStr:
-			err = set(el, v._value)
-		case BenLst:
-			err = unmarshalList(v._value.([]benObject), el)
-		case BenDir:
-			err = unmarshalDir(v._value.(map[string]benObject), el)
+// newPtrDecoder 解码到指针指向的值，nil 指针会被初始化
+func newPtrDecoder(t reflect.Type) decoderFunc {
+	if t.Implements(scannerUnmarshalerType) {
+		return func(d *decodeState, tok Token, v reflect.Value) error {
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			return scannerUnmarshalerDecoder(d, tok, v)
 		}
-		if err != nil {
-			return err
+	}
+	if t.Implements(unmarshalerType) {
+		return func(d *decodeState, tok Token, v reflect.Value) error {
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			return unmarshalerDecoder(d, tok, v)
 		}
-		err = set(newSlice.Index(i), el)
-		if err != nil {
-			return err
+	}
+
+	dec := typeDecoder(t.Elem())
+	return func(d *decodeState, tok Token, v reflect.Value) error {
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
 		}
+		return dec(d, tok, v.Elem())
 	}
+}
+
+func typeErrorDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	return typeError(tok, v.Type())
+}
 
-	err = set(ref, newSlice)
+// interfaceDecoder 只支持空接口，分别使用 int64、string、[]any 和 map[string]any
+func interfaceDecoder(d *decodeState, tok Token, v reflect.Value) error {
+	if v.NumMethod() != 0 {
+		return typeError(tok, v.Type())
+	}
+	val, err := d.anyValue(tok)
 	if err != nil {
-		return ErrColon
+		return err
 	}
+	v.Set(reflect.ValueOf(val))
 	return nil
 }
 
-// unmarshalDir 反序列化字典类型的 BenObject
-func unmarshalDir(bens map[string]benObject, ref reflect.Value) error {
-	el := elem(ref)
-	switch el.Kind() {
-	case reflect.Map, reflect.Interface:
-		return unmarshalMap(bens, el)
-	case reflect.Struct:
-		return unmarshalStruct(bens, el)
+// typeError 返回 bencode 值无法保存到 typ 类型的错误
+func typeError(tok Token, typ reflect.Type) error {
+	return typeErrorValue(tok, tok.Kind.String(), typ)
+}
+
+// typeErrorValue 和 typeError 相同，但使用 value 描述 bencode 值
+func typeErrorValue(tok Token, value string, typ reflect.Type) error {
+	return &UnmarshalTypeError{Value: value, Type: typ, Offset: tok.Offset}
+}
+
+// anyValue 将 tok 开始的值转换为 int64、string、[]any 或 map[string]any
+func (d *decodeState) anyValue(tok Token) (any, error) {
+	switch tok.Kind {
+	case TokenInt:
+		i, err := tok.Int()
+		if err != nil {
+			return nil, typeErrorValue(tok, "int "+string(tok.Value), reflect.TypeOf(i))
+		}
+		return i, nil
+
+	case TokenString:
+		return string(tok.Value), nil
+
+	case TokenList:
+		res := []any{}
+		for {
+			t, err := d.next()
+			if err != nil {
+				return nil, err
+			}
+			if t.Kind == TokenEnd {
+				return res, nil
+			}
+			v, err := d.anyValue(t)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+
+	case TokenDict:
+		res := map[string]any{}
+		for {
+			key, err := d.next()
+			if err != nil {
+				return nil, err
+			}
+			if key.Kind == TokenEnd {
+				return res, nil
+			}
+			t, err := d.next()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.anyValue(t)
+			if err != nil {
+				return nil, err
+			}
+			res[string(key.Value)] = v
+		}
+
 	default:
-		return errors.New("type error: not a map or struct")
+		return nil, d.s.syntaxError(int(tok.Offset-d.s.base), fmt.Errorf("unexpected %s", tok.Kind))
 	}
 }
 
-// unmarshalMap 反序列化字典类型的 BenObject 到 map/any
-func unmarshalMap(bens map[string]benObject, ref reflect.Value) error {
-	refEl := elem(ref)
-	keyTyp := reflect.TypeOf("")
-	valTyp := ref.Type()
-	if ref.Kind() == reflect.Map {
-		valTyp = refEl.Type().Elem()
+// intDecoder 反序列化整数到整数或 bool，超出目标类型范围时返回错误
+func intDecoder(d *decodeState, tok Token, ref reflect.Value) error {
+	if tok.Kind != TokenInt {
+		return typeError(tok, ref.Type())
 	}
+	overflow := typeErrorValue(tok, "int "+string(tok.Value), ref.Type())
 
-	newMap := reflect.MakeMap(reflect.MapOf(keyTyp, valTyp))
-	var err error
-	for key, benv := range bens {
-		val := reflect.New(valTyp)
-		switch benv._type {
-		case BenInt, BenStr:
-			err = set(val, benv._value)
-		case BenLst:
-			err = unmarshalList(benv._value.([]benObject), val)
-		case BenDir:
-			err = unmarshalDir(benv._value.(map[string]benObject), val)
+	switch ref.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := tok.Uint()
+		if err != nil || ref.OverflowUint(u) {
+			return overflow
 		}
-		if err != nil {
-			return err
-		}
-		newMap.SetMapIndex(reflect.ValueOf(key), val)
+		ref.SetUint(u)
+		return nil
 	}
-	err = set(refEl, newMap)
+
+	i, err := tok.Int()
 	if err != nil {
-		return err
+		return overflow
+	}
+	switch ref.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if ref.OverflowInt(i) {
+			return overflow
+		}
+		ref.SetInt(i)
+
+	case reflect.Bool:
+		if i != 0 && i != 1 {
+			return overflow
+		}
+		ref.SetBool(i == 1)
+
+	default:
+		return typeError(tok, ref.Type())
 	}
+	return nil
+}
 
+// stringDecoder 反序列化字符串到 string
+func stringDecoder(d *decodeState, tok Token, ref reflect.Value) error {
+	if tok.Kind != TokenString {
+		return typeError(tok, ref.Type())
+	}
+	ref.SetString(string(tok.Value))
 	return nil
 }
 
-// unmarshalStruct 反序列化字典类型的 BenObject 到 struct
-func unmarshalStruct(bens map[string]benObject, ref reflect.Value) error {
-	el := elem(ref)
+// unmarshalString 反序列化字符串到 []byte 或 [N]byte
+func unmarshalString(tok Token, ref reflect.Value) error {
+	s := tok.Value
+	switch {
+	case ref.Kind() == reflect.Slice && ref.Type().Elem().Kind() == reflect.Uint8:
+		ref.SetBytes(append([]byte{}, s...))
 
-	var err error
-	for i := 0; i < el.NumField(); i++ {
-		tag := el.Type().Field(i).Tag.Get("bencode")
-		if tag == "" {
-			tag = el.Type().Field(i).Name
+	case ref.Kind() == reflect.Array && ref.Type().Elem().Kind() == reflect.Uint8:
+		if ref.Len() != len(s) {
+			return typeErrorValue(tok, fmt.Sprintf("string of length %d", len(s)), ref.Type())
 		}
-		if tag == "-" || unicode.IsLower(rune(el.Type().Field(i).Name[0])) {
-			continue
+		for i := 0; i < len(s); i++ {
+			ref.Index(i).SetUint(uint64(s[i]))
 		}
 
-		v, ok := bens[tag]
-		if !ok {
-			continue
+	default:
+		return typeError(tok, ref.Type())
+	}
+	return nil
+}
+
+// newSliceDecoder 反序列化列表到 slice，元素类型为 uint8 时也可以反序列化字符串
+func newSliceDecoder(t reflect.Type) decoderFunc {
+	dec := typeDecoder(t.Elem())
+	bytes := t.Elem().Kind() == reflect.Uint8
+	return func(d *decodeState, tok Token, ref reflect.Value) error {
+		if bytes && tok.Kind == TokenString {
+			return unmarshalString(tok, ref)
 		}
-		switch v._type {
-		case BenInt, BenStr:
-			err = set(el.Field(i), v._value)
-		case BenLst:
-			err = unmarshalList(v._value.([]benObject), el.Field(i))
-		case BenDir:
-			err = unmarshalDir(v._value.(map[string]benObject), el.Field(i))
+		if tok.Kind != TokenList {
+			return typeError(tok, ref.Type())
+		}
+
+		newSlice := reflect.New(t).Elem()
+		for i := 0; ; i++ {
+			el, err := d.next()
+			if err != nil {
+				return err
+			}
+			if el.Kind == TokenEnd {
+				break
+			}
+			if i >= newSlice.Cap() {
+				newSlice.Grow(1)
+			}
+			newSlice.SetLen(i + 1)
+			err = dec(d, el, newSlice.Index(i))
+			if err != nil {
+				return withField(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+		if newSlice.IsNil() {
+			newSlice = reflect.MakeSlice(t, 0, 0)
+		}
+		ref.Set(newSlice)
+		return nil
+	}
+}
+
+// newArrayDecoder 反序列化列表到 array，array 的长度必须和列表一致，元素类型为 uint8 时也可以反序列化字符串
+func newArrayDecoder(t reflect.Type) decoderFunc {
+	dec := typeDecoder(t.Elem())
+	bytes := t.Elem().Kind() == reflect.Uint8
+	return func(d *decodeState, tok Token, ref reflect.Value) error {
+		if bytes && tok.Kind == TokenString {
+			return unmarshalString(tok, ref)
+		}
+		if tok.Kind != TokenList {
+			return typeError(tok, ref.Type())
+		}
+
+		n := 0
+		for ; ; n++ {
+			el, err := d.next()
+			if err != nil {
+				return err
+			}
+			if el.Kind == TokenEnd {
+				break
+			}
+			if n >= ref.Len() {
+				err = d.s.skip(el)
+			} else {
+				err = dec(d, el, ref.Index(n))
+			}
+			if err != nil {
+				return withField(err, fmt.Sprintf("[%d]", n))
+			}
+		}
+		if n != ref.Len() {
+			return typeErrorValue(tok, fmt.Sprintf("list of length %d", n), ref.Type())
+		}
+		return nil
+	}
+}
+
+// newMapDecoder 反序列化字典到 map，map 的 key 必须是字符串类型
+func newMapDecoder(t reflect.Type) decoderFunc {
+	dec := typeDecoder(t.Elem())
+	return func(d *decodeState, tok Token, ref reflect.Value) error {
+		if tok.Kind != TokenDict {
+			return typeError(tok, ref.Type())
+		}
+		if ref.IsNil() {
+			ref.Set(reflect.MakeMap(t))
+		}
+		for {
+			key, err := d.next()
+			if err != nil {
+				return err
+			}
+			if key.Kind == TokenEnd {
+				return nil
+			}
+
+			el, err := d.next()
+			if err != nil {
+				return err
+			}
+			val := reflect.New(t.Elem()).Elem()
+			err = dec(d, el, val)
+			if err != nil {
+				return withField(err, string(key.Value))
+			}
+			ref.SetMapIndex(reflect.ValueOf(string(key.Value)).Convert(t.Key()), val)
+		}
+	}
+}
+
+// newStructDecoder 反序列化字典到 struct，忽略结构体中不存在的 key
+// 只有 key 存在时才会初始化对应的指针字段，因此可以通过 nil 判断可选的 key 是否存在
+// 多个字段对应同一个 key 时（例如同时使用 RawMessage 和解析后的结构体保存同一个值），每个字段都会被赋值
+func newStructDecoder(t reflect.Type) decoderFunc {
+	plan := cachedFields(t)
+	decs := make([]decoderFunc, len(plan.fields))
+	for i, f := range plan.fields {
+		decs[i] = typeDecoder(f.typ)
+	}
+
+	return func(d *decodeState, tok Token, el reflect.Value) error {
+		if tok.Kind != TokenDict {
+			return typeError(tok, el.Type())
+		}
+
+		var found map[string]bool
+		if len(plan.required) > 0 {
+			found = map[string]bool{}
+		}
+		for {
+			key, err := d.next()
+			if err != nil {
+				return err
+			}
+			if key.Kind == TokenEnd {
+				break
+			}
+
+			t, err := d.next()
+			if err != nil {
+				return err
+			}
+			idx := plan.byKey[string(key.Value)]
+			switch len(idx) {
+			case 0:
+				err = d.s.skip(t)
+			case 1:
+				fv, _ := fieldByIndex(el, plan.fields[idx[0]].index, true)
+				err = decs[idx[0]](d, t, fv)
+			default:
+				err = d.values(t, el, plan, decs, idx)
+			}
+			if err != nil {
+				return withField(err, string(key.Value))
+			}
+			if found != nil {
+				found[string(key.Value)] = true
+			}
+		}
+
+		// 按字段顺序检查，保证错误信息稳定
+		for _, i := range plan.required {
+			f := plan.fields[i]
+			if !found[f.key] {
+				return fmt.Errorf("%w: %q (field %s.%s)", ErrMissingKey, f.key, el.Type().Name(), f.name)
+			}
+		}
+		return nil
+	}
+}
+
+// values 将 tok 开始的值分别反序列化到 idx 对应的每个字段
+func (d *decodeState) values(tok Token, el reflect.Value, plan *structPlan, decs []decoderFunc, idx []int) error {
+	raw, err := d.raw(tok)
+	if err != nil {
+		return err
+	}
+	for _, i := range idx {
+		sd := newDecodeState(d.s.Sub(tok, raw))
+		t, err := sd.next()
+		if err != nil {
+			return err
 		}
+		fv, _ := fieldByIndex(el, plan.fields[i].index, true)
+		err = decs[i](sd, t, fv)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,173 @@
+package bencode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalRequired(t *testing.T) {
+	type info struct {
+		Name        string `bencode:"name,required"`
+		PieceLength int64  `bencode:"piece length,required"`
+	}
+
+	var v info
+	err := Unmarshal([]byte("d4:name1:a12:piece lengthi16384ee"), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "a" || v.PieceLength != 16384 {
+		t.Fatalf("unexpected value %+v", v)
+	}
+
+	err = Unmarshal([]byte("d4:name1:ae"), &v)
+	if !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("want ErrMissingKey, got %v", err)
+	}
+}
+
+func TestUnmarshalTypes(t *testing.T) {
+	var v struct {
+		U8    uint8          `bencode:"u8"`
+		I16   int16          `bencode:"i16"`
+		Bool  bool           `bencode:"bool"`
+		Bytes []byte         `bencode:"bytes"`
+		Hash  [4]byte        `bencode:"hash"`
+		Node  [2]any         `bencode:"node"`
+		Any   any            `bencode:"any"`
+		Map   map[string]int `bencode:"map"`
+	}
+	data := []byte("d3:anyd1:ali1e1:bee4:booli1e5:bytes2:ab4:hash4:abcd3:i16i-300e3:mapd1:ai1ee4:nodel1:xi6881ee2:u8i255ee")
+	err := Unmarshal(data, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.U8 != 255 || v.I16 != -300 || !v.Bool || string(v.Bytes) != "ab" || string(v.Hash[:]) != "abcd" {
+		t.Fatalf("unexpected value %+v", v)
+	}
+	if v.Node[0] != "x" || v.Node[1] != int64(6881) {
+		t.Fatalf("unexpected node %v", v.Node)
+	}
+	dict, ok := v.Any.(map[string]any)
+	if !ok || len(dict) != 1 || dict["a"].([]any)[0] != int64(1) {
+		t.Fatalf("unexpected any %#v", v.Any)
+	}
+	if v.Map["a"] != 1 {
+		t.Fatalf("unexpected map %v", v.Map)
+	}
+
+	var u8 uint8
+	if err := Unmarshal([]byte("i256e"), &u8); !errors.Is(err, ErrType) {
+		t.Fatalf("want overflow error, got %v", err)
+	}
+	var u uint
+	if err := Unmarshal([]byte("i-1e"), &u); !errors.Is(err, ErrType) {
+		t.Fatalf("want overflow error, got %v", err)
+	}
+	var hash [4]byte
+	if err := Unmarshal([]byte("3:abc"), &hash); !errors.Is(err, ErrType) {
+		t.Fatalf("want length error, got %v", err)
+	}
+	var i int64
+	if err := Unmarshal([]byte("i99999999999999999999e"), &i); !errors.Is(err, ErrInt) {
+		t.Fatalf("want int64 overflow error, got %v", err)
+	}
+}
+
+type common struct {
+	Name string `bencode:"name"`
+	Size int64  `bencode:"size"`
+}
+
+type Extra struct {
+	Source string `bencode:"source"`
+}
+
+type embedded struct {
+	common
+	*Extra
+	Size    int64  `bencode:"size,omitempty"` // 覆盖 common.Size
+	Nested  common `bencode:"nested,omitempty"`
+	Private *int64 `bencode:"private"`
+	Comment *string
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	var v embedded
+	v.Name = "a"
+	v.common.Size = 1
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Extra 为 nil，其中的字段被忽略；nil 指针字段被忽略
+	want := "d4:name1:a6:nestedd4:name0:4:sizei0eee"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+
+	var got embedded
+	err = Unmarshal([]byte("d4:name1:b7:privatei0e4:sizei2e6:source3:srce"), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "b" || got.Size != 2 || got.common.Size != 0 {
+		t.Fatalf("unexpected value %+v", got)
+	}
+	if got.Extra == nil || got.Source != "src" {
+		t.Fatalf("embedded pointer not decoded: %+v", got.Extra)
+	}
+	if got.Private == nil || *got.Private != 0 {
+		t.Fatalf("private = %v, want pointer to 0", got.Private)
+	}
+	if got.Comment != nil {
+		t.Fatalf("comment = %q, want nil", *got.Comment)
+	}
+
+	// 指向零值的指针会被编码，用于区分 key 不存在和值为零
+	data, err = Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "d4:name1:b6:nestedd4:name0:4:sizei0ee7:privatei0e4:sizei2e6:source3:srce"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	type metadataMsg struct {
+		MsgType   int   `bencode:"msg_type"`
+		Piece     int   `bencode:"piece"`
+		TotalSize int64 `bencode:"total_size"`
+	}
+	head := "d8:msg_typei1e5:piecei0e10:total_sizei8ee"
+	data := []byte(head + "12345678")
+
+	var msg metadataMsg
+	n, err := DecodePrefix(data, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(head) || string(data[n:]) != "12345678" {
+		t.Fatalf("got n = %d, want %d", n, len(head))
+	}
+	if msg != (metadataMsg{1, 0, 8}) {
+		t.Fatalf("unexpected value %+v", msg)
+	}
+
+	// 类型错误时依然返回值的长度
+	var wrong struct {
+		MsgType string `bencode:"msg_type"`
+	}
+	n, err = DecodePrefix(data, &wrong)
+	if !errors.Is(err, ErrType) || n != len(head) {
+		t.Fatalf("got n = %d, err = %v, want n = %d and ErrType", n, err, len(head))
+	}
+
+	n, err = DecodePrefix([]byte("d8:msg_typei1e5:pie"), &msg)
+	var se *SyntaxError
+	if !errors.As(err, &se) || n != 0 {
+		t.Fatalf("got n = %d, err = %v, want a syntax error", n, err)
+	}
+}
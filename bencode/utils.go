@@ -1,67 +1,434 @@
 package bencode
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
 )
 
 var (
 	ErrNotDictionary = errors.New("not a dictionary")
+	ErrKeyNotFound   = errors.New("key not found")
 )
 
-// TDOO: 允许使用 key1.key2.key3 形式获取嵌套数据
-// GetRaw 通过 key 获取原始数据
-func GetRaw(data []byte, key string) ([]byte, error) {
-	reader := bufio.NewReader(bytes.NewReader(data))
-	first, err := reader.ReadByte()
+// GetRaw 通过路径获取原始数据，返回的数据引用 data 的内容
+// 路径由 . 分隔，字典使用 key，列表使用从 0 开始的下标，例如 info.files.3.path，空路径表示整个值
+// key 中的 . 和 \ 需要使用 \ 转义，例如 info.name\.utf-8
+func GetRaw(data []byte, path string) ([]byte, error) {
+	start, end, err := locate(data, splitPath(path))
 	if err != nil {
 		return nil, err
 	}
-	if first != 'd' {
-		return nil, ErrNotDictionary
+	return data[start:end], nil
+}
+
+// SetRaw 将 path 对应的值替换为 value，返回新的数据，data 本身不会被修改
+// 除了被替换的值之外，其余的字节保持不变，因此修改 announce、comment 等字段不会影响 info hash
+// 如果最后一级是字典中不存在的 key，则按照 key 的顺序插入该 key
+func SetRaw(data []byte, path string, value []byte) ([]byte, error) {
+	err := checkRaw(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+
+	segs := splitPath(path)
+	start, end, err := locate(data, segs)
+	if errors.Is(err, ErrKeyNotFound) && len(segs) > 0 {
+		return insertRaw(data, segs, value)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	return splice(data, start, end, value), nil
+}
+
+// DeleteRaw 删除 path 对应的字典 key 或列表元素，返回新的数据，data 本身不会被修改
+// 其余的字节保持不变，path 不存在时返回 ErrKeyNotFound
+func DeleteRaw(data []byte, path string) ([]byte, error) {
+	segs := splitPath(path)
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("%w: empty path", ErrKeyNotFound)
+	}
+	parent := segs[:len(segs)-1]
+	last := segs[len(segs)-1]
+	start, _, err := locate(data, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newScanReader(data[start:])
+	b, err := r.peek()
+	if err != nil {
+		return nil, scanError(r, err)
+	}
+	var found bool
+	var pos int64
+	switch b[0] {
+	case 'd':
+		r.ReadByte()
+		found, pos, err = findKey(r, last)
+	case 'l':
+		index, aerr := strconv.Atoi(last)
+		if aerr != nil || index < 0 {
+			return nil, fmt.Errorf("%w: invalid list index %s", ErrKeyNotFound, joinPath(segs))
+		}
+		r.ReadByte()
+		found, err = findIndex(r, index)
+		pos = r.off
+	default:
+		return nil, fmt.Errorf("%w: %s is not a dict or list", ErrNotDictionary, joinPath(parent))
+	}
+	if err != nil {
+		return nil, scanError(r, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, joinPath(segs))
+	}
+
+	// r 停留在值之前，跳过值得到结束位置
+	err = scans(r, nil)
+	if err != nil {
+		return nil, scanError(r, err)
+	}
+	return splice(data, start+pos, start+r.off, nil), nil
+}
+
+// insertRaw 在 segs 的上一级字典中按顺序插入 key 和 value
+func insertRaw(data []byte, segs []string, value []byte) ([]byte, error) {
+	parent := segs[:len(segs)-1]
+	key := segs[len(segs)-1]
+	start, _, err := locate(data, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newScanReader(data[start:])
+	b, err := r.peek()
+	if err != nil || b[0] != 'd' {
+		return nil, fmt.Errorf("%w: %s", ErrNotDictionary, joinPath(parent))
+	}
+	r.ReadByte()
+	_, pos, err := findKey(r, key)
+	if err != nil {
+		return nil, scanError(r, err)
+	}
+
+	kv := bytes.NewBuffer(nil)
+	encodeString(kv, key)
+	kv.Write(value)
+	at := start + pos
+	return splice(data, at, at, kv.Bytes()), nil
+}
+
+// splice 返回将 data[start:end] 替换为 value 之后的新数据
+func splice(data []byte, start, end int64, value []byte) []byte {
+	res := make([]byte, 0, int64(len(data))-(end-start)+int64(len(value)))
+	res = append(res, data[:start]...)
+	res = append(res, value...)
+	res = append(res, data[end:]...)
+	return res
+}
+
+// checkRaw 检查 value 是否恰好是一个完整的 bencode 值
+func checkRaw(value []byte) error {
+	r := newScanReader(value)
+	err := scans(r, nil)
+	if err != nil {
+		return scanError(r, err)
+	}
+	if _, err := r.peek(); err != io.EOF {
+		return &SyntaxError{Offset: r.off, Msg: ErrTrailingData.Error(), err: ErrTrailingData}
+	}
+	return nil
+}
+
+// locate 返回 segs 对应的值在 data 中的起止位置
+func locate(data []byte, segs []string) (int64, int64, error) {
+	r := newScanReader(data)
+	for i, seg := range segs {
+		b, err := r.peek()
+		if err != nil {
+			return 0, 0, scanError(r, err)
+		}
+
+		switch b[0] {
+		case 'd':
+			r.ReadByte()
+			found, _, err := findKey(r, seg)
+			if err != nil {
+				return 0, 0, scanError(r, err)
+			}
+			if !found {
+				return 0, 0, fmt.Errorf("%w: %s", ErrKeyNotFound, joinPath(segs[:i+1]))
+			}
+
+		case 'l':
+			index, err := strconv.Atoi(seg)
+			if err != nil || index < 0 {
+				return 0, 0, fmt.Errorf("%w: invalid list index %s", ErrKeyNotFound, joinPath(segs[:i+1]))
+			}
+			r.ReadByte()
+			found, err := findIndex(r, index)
+			if err != nil {
+				return 0, 0, scanError(r, err)
+			}
+			if !found {
+				return 0, 0, fmt.Errorf("%w: index out of range %s", ErrKeyNotFound, joinPath(segs[:i+1]))
+			}
+
+		default:
+			return 0, 0, fmt.Errorf("%w: %s is not a dict or list", ErrNotDictionary, joinPath(segs[:i]))
+		}
+	}
+
+	start := r.off
+	err := scans(r, nil)
+	if err != nil {
+		return 0, 0, scanError(r, err)
+	}
+	return start, r.off, nil
+}
+
+// findKey 在字典中查找 key，r 需要位于字典的 'd' 之后
+// 找到时 r 停留在对应的值之前，否则返回按顺序插入 key 时的位置（相对于 r 的起始位置）
+func findKey(r *scanReader, key string) (bool, int64, error) {
+	insert := int64(-1)
 	for {
-		k, err := decodeString(reader)
+		b, err := r.peek()
 		if err != nil {
-			return nil, err
+			return false, 0, err
 		}
-		if k != key {
-			scans(reader, nil)
-			continue
+		if b[0] == 'e' {
+			if insert < 0 {
+				insert = r.off
+			}
+			return false, insert, nil
 		}
 
+		pos := r.off
 		buf := bytes.NewBuffer(nil)
-		err = scans(reader, buf)
-		return buf.Bytes(), err
+		err = scansString(r, buf)
+		if err != nil {
+			return false, 0, err
+		}
+		_, k, _ := bytes.Cut(buf.Bytes(), []byte{':'})
+		if string(k) == key {
+			return true, pos, nil
+		}
+		if insert < 0 && string(k) > key {
+			insert = pos
+		}
+
+		err = scans(r, nil)
+		if err != nil {
+			return false, 0, err
+		}
+	}
+}
 
+// findIndex 在列表中查找第 index 个元素，r 需要位于列表的 'l' 之后
+// 找到时 r 停留在对应的元素之前
+func findIndex(r *scanReader, index int) (bool, error) {
+	for i := 0; ; i++ {
+		b, err := r.peek()
+		if err != nil {
+			return false, err
+		}
+		if b[0] == 'e' {
+			return false, nil
+		}
+		if i == index {
+			return true, nil
+		}
+		err = scans(r, nil)
+		if err != nil {
+			return false, err
+		}
 	}
 }
 
-func scans(r *bufio.Reader, w *bytes.Buffer) error {
-	b, err := r.Peek(1)
+// splitPath 将路径按照未转义的 . 分隔
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	segs := []string{}
+	var seg strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 < len(path) {
+				i++
+			}
+			seg.WriteByte(path[i])
+		case '.':
+			segs = append(segs, seg.String())
+			seg.Reset()
+		default:
+			seg.WriteByte(path[i])
+		}
+	}
+	return append(segs, seg.String())
+}
+
+// joinPath 是 splitPath 的逆操作，用于错误信息
+func joinPath(segs []string) string {
+	escaped := make([]string, len(segs))
+	for i, seg := range segs {
+		seg = strings.ReplaceAll(seg, `\`, `\\`)
+		escaped[i] = strings.ReplaceAll(seg, ".", `\.`)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// peekReader 最多缓存一个字节的 Reader，不会从底层读取超出当前值的数据
+// 底层实现了 io.ByteReader 时逐字节读取，否则每次只 Read 一个字节
+type peekReader struct {
+	r      io.Reader
+	br     io.ByteReader
+	buf    [1]byte
+	peeked bool // buf 中保存了 peek 读取的字节
+}
+
+func newPeekReader(r io.Reader) *peekReader {
+	br, _ := r.(io.ByteReader)
+	return &peekReader{r: r, br: br}
+}
+
+// ReadByte 读取一个字节
+func (p *peekReader) ReadByte() (byte, error) {
+	if p.peeked {
+		p.peeked = false
+		return p.buf[0], nil
+	}
+	if p.br != nil {
+		return p.br.ReadByte()
+	}
+	_, err := io.ReadFull(p.r, p.buf[:])
+	return p.buf[0], err
+}
+
+// peek 返回下一个字节但不消耗它
+func (p *peekReader) peek() ([]byte, error) {
+	if !p.peeked {
+		b, err := p.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		p.buf[0] = b
+		p.peeked = true
+	}
+	return p.buf[:], nil
+}
+
+// Read 实现 io.Reader，先返回 peek 缓存的字节
+func (p *peekReader) Read(b []byte) (int, error) {
+	if p.peeked && len(b) > 0 {
+		b[0] = p.buf[0]
+		p.peeked = false
+		return 1, nil
+	}
+	return p.r.Read(b)
+}
+
+// scanReader 在 peekReader 的基础上记录偏移量，供 scans 系列函数使用
+type scanReader struct {
+	*peekReader
+	off    int64    // 已读取的字节数
+	depth  int      // 当前嵌套深度
+	index  *indexer // 不为 nil 时记录每个值的位置
+	limits Limits   // 只检查 MaxStringLen、MaxDepth 和 MaxInputSize
+}
+
+func newScanReader(data []byte) *scanReader {
+	return &scanReader{
+		peekReader: newPeekReader(bytes.NewReader(data)),
+		limits:     Limits{MaxDepth: DefaultLimits.MaxDepth},
+	}
+}
+
+// ReadByte 读取一个字节并记录偏移量
+func (r *scanReader) ReadByte() (byte, error) {
+	if r.limits.MaxInputSize > 0 && r.off >= r.limits.MaxInputSize {
+		return 0, r.limitError("MaxInputSize", r.limits.MaxInputSize)
+	}
+	b, err := r.peekReader.ReadByte()
+	if err == nil {
+		r.off++
+	}
+	return b, err
+}
+
+// limitError 返回 r 当前位置超出限制的错误
+func (r *scanReader) limitError(limit string, max int64) *LimitError {
+	return &LimitError{Limit: limit, Max: max, Offset: r.off}
+}
+
+// copyN 读取 n 个字节写入 w，w 为 nil 时直接丢弃
+func (r *scanReader) copyN(w *bytes.Buffer, n int64) error {
+	var dst io.Writer = io.Discard
+	if w != nil {
+		dst = w
+	}
+	read, err := io.CopyN(dst, r.peekReader, n)
+	r.off += read
+	return err
+}
+
+// scanError 将 scans 系列函数返回的错误包装为带有偏移量的 *SyntaxError
+func scanError(r *scanReader, err error) error {
+	var le *LimitError
+	if errors.As(err, &le) {
+		return le
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return &SyntaxError{Offset: r.off, Msg: err.Error(), err: err}
+}
+
+// scans 从 r 中读取一个完整的值并原样写入 w，w 为 nil 时只跳过该值
+func scans(r *scanReader, w *bytes.Buffer) error {
+	b, err := r.peek()
 	if err != nil {
 		return err
 	}
 
+	var typ BenType
+	var scan func(*scanReader, *bytes.Buffer) error
 	switch b[0] {
 	case 'i':
-		return scansInt(r, w)
+		typ, scan = BenInt, scansInt
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		return scansString(r, w)
-	case 'l', 'd':
-		return scansDL(r, w)
+		typ, scan = BenStr, scansString
+	case 'l':
+		typ, scan = BenLst, scansDL
+	case 'd':
+		typ, scan = BenDir, scansDL
 	default:
-		return errors.New("invalid data")
+		return fmt.Errorf("%w %q", ErrUnknowByte, b[0])
+	}
+
+	if r.index == nil {
+		return scan(r, w)
 	}
+	r.index.open(typ, r.off)
+	err = scan(r, w)
+	r.index.close(r.off, err != nil)
+	return err
 }
 
-func scansInt(r *bufio.Reader, w *bytes.Buffer) error {
+func scansInt(r *scanReader, w *bytes.Buffer) error {
 	var b byte
 	var err error
 
-	for {
+	for i := 0; ; i++ {
 		b, err = r.ReadByte()
 		if err != nil {
 			return err
@@ -71,13 +438,17 @@ func scansInt(r *bufio.Reader, w *bytes.Buffer) error {
 			w.WriteByte(b)
 		}
 
-		if b == 'e' {
+		switch {
+		case i == 0 && b == 'i', i == 1 && b == '-', b >= '0' && b <= '9':
+		case i > 0 && b == 'e':
 			return nil
+		default:
+			return fmt.Errorf("%w: unexpected byte %q", ErrInt, b)
 		}
 	}
 }
 
-func scansString(r *bufio.Reader, w *bytes.Buffer) error {
+func scansString(r *scanReader, w *bytes.Buffer) error {
 	len := 0
 	for {
 		b, err := r.ReadByte()
@@ -90,54 +461,82 @@ func scansString(r *bufio.Reader, w *bytes.Buffer) error {
 		if b == ':' {
 			break
 		}
-		len = len*10 + int(b-'0')
-	}
-
-	for i := 0; i < len; i++ {
-		b, err := r.ReadByte()
-		if err != nil {
-			return err
+		if b < '0' || b > '9' {
+			return fmt.Errorf("%w, got %q", ErrColon, b)
 		}
-		if w != nil {
-			w.WriteByte(b)
+		if len > (math.MaxInt-int(b-'0'))/10 {
+			return fmt.Errorf("%w: length overflows int", ErrStr)
 		}
+		len = len*10 + int(b-'0')
+	}
+	if r.limits.MaxStringLen > 0 && len > r.limits.MaxStringLen {
+		return r.limitError("MaxStringLen", int64(r.limits.MaxStringLen))
+	}
+	if r.limits.MaxInputSize > 0 && r.off+int64(len) > r.limits.MaxInputSize {
+		return r.limitError("MaxInputSize", r.limits.MaxInputSize)
 	}
 
-	return nil
+	return r.copyN(w, int64(len))
 }
 
-func scansDL(r *bufio.Reader, w *bytes.Buffer) error {
-	b, err := r.ReadByte()
+func scansDL(r *scanReader, w *bytes.Buffer) error {
+	first, err := r.ReadByte()
 	if err != nil {
 		return err
 	}
 	if w != nil {
-		w.WriteByte(b)
+		w.WriteByte(first)
 	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return r.limitError("MaxDepth", int64(r.limits.MaxDepth))
+	}
+
 	for {
-		err = scans(r, w)
+		b, err := r.peek()
 		if err != nil {
 			return err
 		}
 
-		if b == 'd' {
-			err = scans(r, w)
+		if b[0] == 'e' {
+			bb, err := r.ReadByte()
+			if w != nil {
+				w.WriteByte(bb)
+			}
+			return err
+		}
+
+		if first == 'd' {
+			err = scansKey(r, w)
 			if err != nil {
 				return err
 			}
 		}
 
-		b, err := r.Peek(1)
+		err = scans(r, w)
 		if err != nil {
 			return err
 		}
+	}
+}
 
-		if b[0] == 'e' {
-			bb, err := r.ReadByte()
-			if w != nil {
-				w.WriteByte(bb)
-			}
-			return err
-		}
+// scansKey 读取字典的 key，记录位置时同时保存 key 的内容
+func scansKey(r *scanReader, w *bytes.Buffer) error {
+	if r.index == nil {
+		return scansString(r, w)
 	}
+
+	buf := bytes.NewBuffer(nil)
+	err := scansString(r, buf)
+	if err != nil {
+		return err
+	}
+	if w != nil {
+		w.Write(buf.Bytes())
+	}
+	_, k, _ := bytes.Cut(buf.Bytes(), []byte{':'})
+	r.index.key = string(k)
+	return nil
 }
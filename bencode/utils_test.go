@@ -0,0 +1,119 @@
+package bencode
+
+import (
+	"errors"
+	"testing"
+)
+
+var rawTorrent = []byte("d8:announce3:url7:comment2:hi4:infod5:filesld6:lengthi1e4:pathl1:aeed6:lengthi2e4:pathl1:b1:ceee4:name3:dir10:name.utf-83:dire1:xdee")
+
+func TestGetRaw(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", string(rawTorrent)},
+		{"announce", "3:url"},
+		{"info.files.1.path", "l1:b1:ce"},
+		{"info.files.1.path.1", "1:c"},
+		{"info.files.0", "d6:lengthi1e4:pathl1:aee"},
+		{`info.name\.utf-8`, "3:dir"},
+		{"x", "de"},
+	}
+	for _, tt := range tests {
+		got, err := GetRaw(rawTorrent, tt.path)
+		if err != nil {
+			t.Errorf("%q: %v", tt.path, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"missing", "info.files.2", "info.files.a", "x.y"} {
+		if _, err := GetRaw(rawTorrent, path); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("%q: want ErrKeyNotFound, got %v", path, err)
+		}
+	}
+	if _, err := GetRaw(rawTorrent, "announce.x"); !errors.Is(err, ErrNotDictionary) {
+		t.Errorf("want ErrNotDictionary, got %v", err)
+	}
+
+	// 缺少 key 或者数据被截断时不能死循环
+	for _, data := range []string{"d3:fooi1e", "d3:foo", "d", "d3:fooxe"} {
+		if _, err := GetRaw([]byte(data), "bar"); err == nil {
+			t.Errorf("%q: want error", data)
+		}
+	}
+}
+
+func TestSetRaw(t *testing.T) {
+	info, _ := GetRaw(rawTorrent, "info")
+
+	out, err := SetRaw(rawTorrent, "announce", []byte("9:udp://foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d8:announce9:udp://foo7:comment2:hi4:infod5:filesld6:lengthi1e4:pathl1:aeed6:lengthi2e4:pathl1:b1:ceee4:name3:dir10:name.utf-83:dire1:xdee"
+	if string(out) != want {
+		t.Fatalf("got %q", out)
+	}
+	if got, _ := GetRaw(out, "info"); string(got) != string(info) {
+		t.Fatalf("info changed: %q", got)
+	}
+
+	// 插入不存在的 key 时保持 key 的顺序
+	out, err = SetRaw(rawTorrent, "created by", []byte("4:test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Valid(out); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	if got, _ := GetRaw(out, "created by"); string(got) != "4:test" {
+		t.Fatalf("got %q", got)
+	}
+
+	out, err = SetRaw(rawTorrent, "x.y", []byte("i1e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetRaw(out, "x"); string(got) != "d1:yi1ee" {
+		t.Fatalf("got %q", got)
+	}
+
+	if _, err := SetRaw(rawTorrent, "comment", []byte("2:hi3:bad")); err == nil {
+		t.Fatal("want error for invalid value")
+	}
+	if _, err := SetRaw(rawTorrent, "info.files.5", []byte("i1e")); !errors.Is(err, ErrNotDictionary) {
+		t.Fatalf("want ErrNotDictionary, got %v", err)
+	}
+}
+
+func TestDeleteRaw(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"announce", "d7:comment2:hi4:infod5:filesld6:lengthi1e4:pathl1:aeed6:lengthi2e4:pathl1:b1:ceee4:name3:dir10:name.utf-83:dire1:xdee"},
+		{"x", "d8:announce3:url7:comment2:hi4:infod5:filesld6:lengthi1e4:pathl1:aeed6:lengthi2e4:pathl1:b1:ceee4:name3:dir10:name.utf-83:diree"},
+		{"info.files.0", "d8:announce3:url7:comment2:hi4:infod5:filesld6:lengthi2e4:pathl1:b1:ceee4:name3:dir10:name.utf-83:dire1:xdee"},
+		{`info.name\.utf-8`, "d8:announce3:url7:comment2:hi4:infod5:filesld6:lengthi1e4:pathl1:aeed6:lengthi2e4:pathl1:b1:ceee4:name3:dire1:xdee"},
+	}
+	for _, tt := range tests {
+		out, err := DeleteRaw(rawTorrent, tt.path)
+		if err != nil || string(out) != tt.want {
+			t.Errorf("DeleteRaw(%q) = %q, %v", tt.path, out, err)
+		}
+	}
+
+	for _, path := range []string{"", "missing", "info.files.2", "info.files.x"} {
+		if _, err := DeleteRaw(rawTorrent, path); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("DeleteRaw(%q): want ErrKeyNotFound, got %v", path, err)
+		}
+	}
+	if _, err := DeleteRaw(rawTorrent, "announce.x"); !errors.Is(err, ErrNotDictionary) {
+		t.Fatalf("want ErrNotDictionary, got %v", err)
+	}
+}
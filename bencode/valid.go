@@ -0,0 +1,21 @@
+package bencode
+
+// Valid 按照 BEP 3 严格校验 data 是否恰好是一个规范的 bencode 值
+// 数据不合法时返回 *SyntaxError，可以通过 errors.Is 判断具体原因，例如 ErrNonCanonical、ErrUnsorted、ErrTrailingData
+func Valid(data []byte) error {
+	s := NewScanner(data)
+	s.Strict()
+	err := newDecodeState(s).skipValue()
+	if err != nil {
+		return err
+	}
+	return trailing(s)
+}
+
+// trailing 检查 s 在第一个值之后是否还有多余的数据
+func trailing(s *Scanner) error {
+	if s.off < len(s.data) {
+		return s.syntaxError(s.off, ErrTrailingData)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package bencode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	valid := []string{
+		"i0e",
+		"i-1e",
+		"i42e",
+		"0:",
+		"4:spam",
+		"le",
+		"de",
+		"d1:ai0e1:bli1e0:ee",
+	}
+	for _, data := range valid {
+		if err := Valid([]byte(data)); err != nil {
+			t.Errorf("%q: unexpected error %v", data, err)
+		}
+	}
+
+	invalid := []struct {
+		data   string
+		target error
+	}{
+		{"i01e", ErrNonCanonical},
+		{"i-0e", ErrNonCanonical},
+		{"i-01e", ErrNonCanonical},
+		{"ie", ErrInt},
+		{"i-e", ErrInt},
+		{"i1-e", ErrInt},
+		{"04:spam", ErrNonCanonical},
+		{"d:i1ee", ErrStr},
+		{"99999999999999999999:a", ErrStr},
+		{"d1:bi1e1:ai2ee", ErrUnsorted},
+		{"d1:ai1e1:ai2ee", nil},
+		{"i1ei2e", ErrTrailingData},
+		{"4:spamx", ErrTrailingData},
+		{"", nil},
+	}
+	for _, tt := range invalid {
+		err := Valid([]byte(tt.data))
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%q: want *SyntaxError, got %v", tt.data, err)
+			continue
+		}
+		if tt.target != nil && !errors.Is(err, tt.target) {
+			t.Errorf("%q: want %v, got %v", tt.data, tt.target, err)
+		}
+	}
+
+	// 非严格模式下允许不规范的整数
+	var i int64
+	if err := Unmarshal([]byte("i007e"), &i); err != nil || i != 7 {
+		t.Errorf("lenient decode: %v %d", err, i)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	strict := DecodeOptions{Strict: true}
+	inputs := []string{
+		"i42e", "d1:ai0e1:bli1e0:ee",
+		"i01e", "04:spam", "d1:bi1e1:ai2ee", "d1:ai1e1:ai2ee", "i1ei2e", "4:spamx", "",
+	}
+	// 严格模式的 Unmarshal 与 Valid 的结果相同
+	for _, data := range inputs {
+		var v any
+		got := strict.Unmarshal([]byte(data), &v)
+		want := Valid([]byte(data))
+		if (got == nil) != (want == nil) || got != nil && got.Error() != want.Error() {
+			t.Errorf("%q: got %v, want %v", data, got, want)
+		}
+	}
+
+	// 非严格模式忽略多余的数据，严格模式拒绝
+	var i int
+	if err := Unmarshal([]byte("i1ei2e"), &i); err != nil || i != 1 {
+		t.Fatalf("lenient: %v %d", err, i)
+	}
+	err := strict.Unmarshal([]byte("i1ei2e"), &i)
+	if !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("got %v, want ErrTrailingData", err)
+	}
+	if err := strict.Unmarshal([]byte("i1e"), i); !errors.Is(err, ErrNotPtr) {
+		t.Fatalf("got %v, want ErrNotPtr", err)
+	}
+
+	// 类型错误优先于之后的多余数据
+	var s string
+	err = strict.Unmarshal([]byte("i1ex"), &s)
+	var te *UnmarshalTypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v, want *UnmarshalTypeError", err)
+	}
+}
@@ -0,0 +1,234 @@
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Value 解析后的 bencode 值，可以在不定义 Go 结构体的情况下查看任意 bencode 数据
+// Value 的零值类型为 BenNone
+type Value struct {
+	obj *benObject
+}
+
+// Parse 解析 data 中的一个完整的 bencode 值，data 中值之后不允许有多余的数据
+// 返回的 Value 的 Raw 引用 data 的内容
+func Parse(data []byte) (Value, error) {
+	return DecodeOptions{}.Parse(data)
+}
+
+// Parse 与包级别的 Parse 相同，按照 o 的严格模式和资源限制解析
+func (o DecodeOptions) Parse(data []byte) (Value, error) {
+	s := o.scanner(data)
+	bo, err := parser(s)
+	if err != nil {
+		return Value{}, err
+	}
+	err = trailing(s)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{bo}, nil
+}
+
+// Type 返回值的类型
+func (v Value) Type() BenType {
+	if v.obj == nil {
+		return BenNone
+	}
+	return v.obj._type
+}
+
+// Int 返回整数值，不是整数时返回 ErrInt
+func (v Value) Int() (int64, error) {
+	if v.Type() != BenInt {
+		return 0, ErrInt
+	}
+	return v.obj._value.(int64), nil
+}
+
+// Bytes 返回字符串的内容，不是字符串时返回 ErrStr
+func (v Value) Bytes() ([]byte, error) {
+	if v.Type() != BenStr {
+		return nil, ErrStr
+	}
+	return []byte(v.obj._value.(string)), nil
+}
+
+// List 返回列表的元素，不是列表时返回 ErrLst
+func (v Value) List() ([]Value, error) {
+	if v.Type() != BenLst {
+		return nil, ErrLst
+	}
+	bens := v.obj._value.([]benObject)
+	res := make([]Value, len(bens))
+	for i := range bens {
+		res[i] = Value{&bens[i]}
+	}
+	return res, nil
+}
+
+// Dict 返回字典的内容，不是字典时返回 ErrDic
+func (v Value) Dict() (map[string]Value, error) {
+	if v.Type() != BenDir {
+		return nil, ErrDic
+	}
+	bens := v.obj._value.(map[string]benObject)
+	res := make(map[string]Value, len(bens))
+	for k, bo := range bens {
+		res[k] = Value{&bo}
+	}
+	return res, nil
+}
+
+// Get 返回字典中 key 对应的值，不是字典时返回 ErrDic，key 不存在时返回 ErrKeyNotFound
+func (v Value) Get(key string) (Value, error) {
+	if v.Type() != BenDir {
+		return Value{}, ErrDic
+	}
+	bo, ok := v.obj._value.(map[string]benObject)[key]
+	if !ok {
+		return Value{}, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+	return Value{&bo}, nil
+}
+
+// Keys 返回字典的 key，按照在输入中出现的顺序排列，不是字典时返回 nil
+func (v Value) Keys() []string {
+	if v.Type() != BenDir {
+		return nil
+	}
+	return append([]string(nil), v.obj._keys...)
+}
+
+// Range 按照 key 在输入中出现的顺序遍历字典，f 返回 false 时停止遍历，不是字典时返回 ErrDic
+func (v Value) Range(f func(key string, val Value) bool) error {
+	if v.Type() != BenDir {
+		return ErrDic
+	}
+	bens := v.obj._value.(map[string]benObject)
+	for _, k := range v.obj._keys {
+		bo := bens[k]
+		if !f(k, Value{&bo}) {
+			break
+		}
+	}
+	return nil
+}
+
+// Raw 返回该值在输入中的原始数据
+func (v Value) Raw() []byte {
+	if v.obj == nil {
+		return nil
+	}
+	return v.obj._raw
+}
+
+// Offset 返回该值在输入中的偏移量
+func (v Value) Offset() int64 {
+	if v.obj == nil {
+		return 0
+	}
+	return v.obj._offset
+}
+
+// Encode 将值重新编码为 bencode 数据，字典的 key 保持在输入中的顺序
+func (v Value) Encode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := v.obj.encode(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencode 实现 Marshaler
+func (v Value) MarshalBencode() ([]byte, error) {
+	return v.Encode()
+}
+
+// UnmarshalBencode 实现 Unmarshaler，可以在结构体中使用 Value 保存未知结构的字段
+func (v *Value) UnmarshalBencode(data []byte) error {
+	parsed, err := Parse(bytes.Clone(data))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// UnmarshalBencodeFrom 实现 ScannerUnmarshaler，作为字段解码时使用外层的严格模式和资源限制
+// Raw 引用输入数据的副本，Offset 为在外层输入中的偏移量
+func (v *Value) UnmarshalBencodeFrom(s *Scanner, tok Token) error {
+	bo, err := newDecodeState(s).object(tok)
+	if err != nil {
+		return err
+	}
+	bo.rebase(bytes.Clone(bo._raw), bo._offset)
+	*v = Value{bo}
+	return nil
+}
+
+// rebase 将 _raw 改为引用 data，data 为输入中从偏移量 base 开始的数据
+func (bo *benObject) rebase(data []byte, base int64) {
+	start := bo._offset - base
+	bo._raw = data[start : start+int64(len(bo._raw))]
+	switch bo._type {
+	case BenLst:
+		bens := bo._value.([]benObject)
+		for i := range bens {
+			bens[i].rebase(data, base)
+		}
+	case BenDir:
+		bens := bo._value.(map[string]benObject)
+		for k, child := range bens {
+			child.rebase(data, base)
+			bens[k] = child
+		}
+	}
+}
+
+// encode 将 benObject 编码到 buf 中
+func (bo *benObject) encode(buf *bytes.Buffer) error {
+	if bo == nil {
+		return fmt.Errorf("%w: empty value", ErrUnsupportedType)
+	}
+
+	switch bo._type {
+	case BenInt:
+		return encodeInt(buf, bo._value.(int64))
+
+	case BenStr:
+		return encodeString(buf, bo._value.(string))
+
+	case BenLst:
+		buf.WriteByte('l')
+		bens := bo._value.([]benObject)
+		for i := range bens {
+			err := bens[i].encode(buf)
+			if err != nil {
+				return err
+			}
+		}
+		return buf.WriteByte('e')
+
+	case BenDir:
+		buf.WriteByte('d')
+		bens := bo._value.(map[string]benObject)
+		for _, k := range bo._keys {
+			err := encodeString(buf, k)
+			if err != nil {
+				return err
+			}
+			v := bens[k]
+			err = v.encode(buf)
+			if err != nil {
+				return err
+			}
+		}
+		return buf.WriteByte('e')
+
+	default:
+		return fmt.Errorf("%w: empty value", ErrUnsupportedType)
+	}
+}
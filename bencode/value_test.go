@@ -0,0 +1,136 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	data := []byte("d8:announce3:url4:infod6:lengthi10e4:name1:ae5:nodesll1:ai1eeee")
+	v, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Type() != BenDir {
+		t.Fatalf("type %s, want dict", v.Type())
+	}
+
+	keys := v.Keys()
+	if len(keys) != 3 || keys[0] != "announce" || keys[1] != "info" || keys[2] != "nodes" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	announce, err := v.Get("announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := announce.Bytes(); err != nil || string(b) != "url" {
+		t.Fatalf("announce %q %v", b, err)
+	}
+	if _, err := announce.Int(); !errors.Is(err, ErrInt) {
+		t.Fatalf("want ErrInt, got %v", err)
+	}
+
+	info, _ := v.Get("info")
+	if !bytes.Equal(info.Raw(), []byte("d6:lengthi10e4:name1:ae")) || info.Offset() != 22 {
+		t.Fatalf("info raw %q offset %d", info.Raw(), info.Offset())
+	}
+	length, _ := info.Get("length")
+	if i, err := length.Int(); err != nil || i != 10 {
+		t.Fatalf("length %d %v", i, err)
+	}
+	if _, err := info.Get("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("want ErrKeyNotFound, got %v", err)
+	}
+
+	nodes, _ := v.Get("nodes")
+	list, err := nodes.List()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("nodes %v %v", list, err)
+	}
+
+	var visited []string
+	v.Range(func(key string, val Value) bool {
+		visited = append(visited, key)
+		return key != "info"
+	})
+	if len(visited) != 2 {
+		t.Fatalf("unexpected range %v", visited)
+	}
+
+	out, err := v.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("got %q, want %q", out, data)
+	}
+
+	if _, err := Parse([]byte("i1ei2e")); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("want ErrTrailingData, got %v", err)
+	}
+}
+
+func TestValueField(t *testing.T) {
+	var v struct {
+		Name  string `bencode:"name"`
+		Extra Value  `bencode:"extra"`
+	}
+	data := []byte("d5:extrad1:xli1eee4:name1:ae")
+	if err := Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Extra.Type() != BenDir {
+		t.Fatalf("unexpected extra %v", v.Extra.Type())
+	}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("got %q, want %q", out, data)
+	}
+}
+
+func TestValueFieldOptions(t *testing.T) {
+	var v struct {
+		Extra Value `bencode:"extra"`
+	}
+	deep := strings.Repeat("l", 100) + "i1e" + strings.Repeat("e", 100)
+	data := []byte("d5:extra" + deep + "e")
+
+	// 作为字段解码时使用外层的资源限制
+	err := Unmarshal(data, &v)
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxDepth" {
+		t.Fatalf("got %v, want MaxDepth", err)
+	}
+	err = DecodeOptions{Limits: &Limits{}}.Unmarshal(data, &v)
+	if err != nil || string(v.Extra.Raw()) != deep {
+		t.Fatalf("unlimited: %v", err)
+	}
+	err = DecodeOptions{Limits: &Limits{MaxElements: 3}}.Unmarshal([]byte("d5:extrali1ei2ei3eee"), &v)
+	if !errors.As(err, &le) || le.Limit != "MaxElements" {
+		t.Fatalf("got %v, want MaxElements", err)
+	}
+
+	// 作为字段解码时使用外层的严格模式
+	data = []byte("d5:extrad1:bi1e1:ai2eee")
+	err = DecodeOptions{Strict: true}.Unmarshal(data, &v)
+	if !errors.Is(err, ErrUnsorted) {
+		t.Fatalf("got %v, want ErrUnsorted", err)
+	}
+
+	// Raw 引用输入数据的副本，Offset 为在外层输入中的偏移量
+	err = Unmarshal(data, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clear(data)
+	b, _ := v.Extra.Get("b")
+	if string(v.Extra.Raw()) != "d1:bi1e1:ai2ee" || string(b.Raw()) != "i1e" || b.Offset() != 12 {
+		t.Fatalf("raw %q, b %q at %d", v.Extra.Raw(), b.Raw(), b.Offset())
+	}
+}
@@ -0,0 +1,774 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const bencodePath = "github.com/alctny/torrent/bencode"
+
+var (
+	byteSliceType    = types.NewSlice(types.Typ[types.Byte])
+	errorType        = types.Universe.Lookup("error").Type()
+	marshalerIface   = newInterface("MarshalBencode", nil, []types.Type{byteSliceType, errorType})
+	unmarshalerIface = newInterface("UnmarshalBencode", []types.Type{byteSliceType}, []types.Type{errorType})
+)
+
+// newInterface 构造只有一个方法的接口，与 bencode.Marshaler、bencode.Unmarshaler 相同
+// 不从 bencode 包中查找，被处理的包不一定导入了 bencode
+func newInterface(name string, params, results []types.Type) *types.Interface {
+	tuple := func(ts []types.Type) *types.Tuple {
+		vars := make([]*types.Var, len(ts))
+		for i, t := range ts {
+			vars[i] = types.NewVar(token.NoPos, nil, "", t)
+		}
+		return types.NewTuple(vars...)
+	}
+	sig := types.NewSignatureType(nil, nil, nil, tuple(params), tuple(results), false)
+	iface := types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, name, sig)}, nil)
+	return iface.Complete()
+}
+
+// step 字段访问路径中的一级
+type step struct {
+	name string
+	ptr  bool       // 嵌入的结构体指针，访问之后的字段前需要判断是否为 nil
+	elem types.Type // 嵌入指针指向的类型
+}
+
+// field 结构体中对应一个 bencode key 的字段，规则与 bencode 包的 typeFields 相同
+type field struct {
+	name      string
+	key       string
+	path      []step
+	depth     int
+	typ       types.Type
+	omitEmpty bool
+	required  bool
+}
+
+// expr 返回访问该字段的表达式
+func (f field) expr() string {
+	names := []string{"x"}
+	for _, s := range f.path {
+		names = append(names, s.name)
+	}
+	return strings.Join(names, ".")
+}
+
+// embedded 返回路径中嵌入指针的访问表达式
+func (f field) embedded() []string {
+	var res []string
+	for i, s := range f.path[:len(f.path)-1] {
+		if s.ptr {
+			res = append(res, field{path: f.path[:i+1]}.expr())
+		}
+	}
+	return res
+}
+
+// plan 结构体类型的字段信息
+type plan struct {
+	fields []field
+	keys   []string
+	byKey  map[string][]int
+}
+
+// generator 保存生成的代码和需要导入的包
+type generator struct {
+	pkg     *types.Package
+	types   map[string]*types.Named
+	plans   map[string]*plan
+	imports map[string]string // 导入路径 -> 包名
+	buf     bytes.Buffer
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	return &generator{
+		pkg:     pkg,
+		types:   map[string]*types.Named{},
+		plans:   map[string]*plan{},
+		imports: map[string]string{},
+	}
+}
+
+// addType 检查类型 name 是否可以生成方法
+func (g *generator) addType(name string) error {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("type %s not found in package %s", name, g.pkg.Name())
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return fmt.Errorf("%s is not a defined type", name)
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s is not a struct type", name)
+	}
+	for _, m := range []string{"MarshalBencode", "MarshalBencodeTo", "UnmarshalBencode", "UnmarshalBencodeFrom"} {
+		if o, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, g.pkg, m); o != nil {
+			return fmt.Errorf("%s already has method or field %s", name, m)
+		}
+	}
+	g.types[name] = named
+	g.plans[name] = typeFields(st)
+	return nil
+}
+
+// typeFields 解析结构体的字段，与 bencode 包相同，嵌入结构体的字段被提升到外层，同一个 key 只保留层级最浅的字段
+func typeFields(st *types.Struct) *plan {
+	var fields []field
+	depths := map[string]int{}
+	visiting := map[types.Type]bool{}
+
+	var walk func(st *types.Struct, t types.Type, path []step, depth int)
+	walk = func(st *types.Struct, t types.Type, path []step, depth int) {
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := 0; i < st.NumFields(); i++ {
+			v := st.Field(i)
+			tag, opts, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("bencode"), ",")
+			if tag == "-" {
+				continue
+			}
+			p := append(append([]step{}, path...), step{name: v.Name()})
+
+			if v.Embedded() && tag == "" {
+				ft := v.Type()
+				if ptr, ok := ft.Underlying().(*types.Pointer); ok {
+					if !v.Exported() {
+						continue
+					}
+					ft = ptr.Elem()
+					p[len(p)-1].ptr = true
+					p[len(p)-1].elem = ft
+				}
+				if sub, ok := ft.Underlying().(*types.Struct); ok {
+					if !visiting[ft] {
+						walk(sub, ft, p, depth+1)
+					}
+					continue
+				}
+			}
+			if !v.Exported() {
+				continue
+			}
+			if tag == "" {
+				tag = v.Name()
+			}
+
+			if d, ok := depths[tag]; !ok || depth < d {
+				depths[tag] = depth
+			}
+			fields = append(fields, field{
+				name:      v.Name(),
+				key:       tag,
+				path:      p,
+				depth:     depth,
+				typ:       v.Type(),
+				omitEmpty: hasOption(opts, "omitempty"),
+				required:  hasOption(opts, "required"),
+			})
+		}
+	}
+	walk(st, st, nil, 0)
+
+	p := &plan{byKey: map[string][]int{}}
+	for _, f := range fields {
+		if f.depth != depths[f.key] {
+			continue
+		}
+		if _, ok := p.byKey[f.key]; !ok {
+			p.keys = append(p.keys, f.key)
+		}
+		p.byKey[f.key] = append(p.byKey[f.key], len(p.fields))
+		p.fields = append(p.fields, f)
+	}
+	sort.Strings(p.keys)
+	return p
+}
+
+// hasOption 判断标签选项中是否包含 opt
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var name string
+		name, opts, _ = strings.Cut(opts, ",")
+		if name == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// p 写入一行代码
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// format 返回完整的格式化后的源码
+func (g *generator) format(args string) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by \"bencodegen %s\"; DO NOT EDIT.\n\n", args)
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// 标准库在前，其他包在后，之间空一行
+	sort.Slice(paths, func(i, j int) bool {
+		if si, sj := isStd(paths[i]), isStd(paths[j]); si != sj {
+			return si
+		}
+		return paths[i] < paths[j]
+	})
+	out.WriteString("import (\n")
+	for i, path := range paths {
+		if i > 0 && isStd(paths[i-1]) && !isStd(path) {
+			out.WriteByte('\n')
+		}
+		if name := g.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&out, "%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&out, "%q\n", path)
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// isStd 判断 path 是否是标准库中的包
+func isStd(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// use 记录生成的代码使用了标准库中的包
+func (g *generator) use(path string) {
+	g.imports[path] = path[strings.LastIndex(path, "/")+1:]
+}
+
+// typeString 返回 t 在生成的代码中的写法，并记录需要导入的包
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// generated 判断 t 是否是本次生成方法的类型，或者已经有 method 方法（例如在其他包中生成的类型）
+func (g *generator) generated(t types.Type, method string) bool {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() == g.pkg && g.types[named.Obj().Name()] == named {
+		return true
+	}
+	if _, ok := t.Underlying().(*types.Pointer); ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, g.pkg, method)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// genType 生成类型 name 的全部方法
+func (g *generator) genType(name string) {
+	g.use("bytes")
+	g.imports[bencodePath] = "bencode"
+	g.genMarshal(name, g.plans[name])
+	g.genUnmarshal(name, g.plans[name])
+}
+
+// genMarshal 生成 MarshalBencode，结构体的值接收者与反射编码时一致
+func (g *generator) genMarshal(name string, p *plan) {
+	g.p("")
+	g.p("// MarshalBencode 实现 bencode.Marshaler")
+	g.p("func (x %s) MarshalBencode() ([]byte, error) {", name)
+	g.p("buf := bytes.NewBuffer(nil)")
+	g.p("err := x.MarshalBencodeTo(buf)")
+	g.p("if err != nil {")
+	g.p("return nil, err")
+	g.p("}")
+	g.p("return buf.Bytes(), nil")
+	g.p("}")
+
+	g.p("")
+	g.p("// MarshalBencodeTo 实现 bencode.BufferMarshaler")
+	g.p("func (x *%s) MarshalBencodeTo(buf *bytes.Buffer) error {", name)
+	g.p("buf.WriteByte('d')")
+	for _, key := range p.keys {
+		g.genKey(p, key)
+	}
+	g.p("buf.WriteByte('e')")
+	g.p("return nil")
+	g.p("}")
+}
+
+// genKey 生成编码一个 key 的代码
+// 多个字段对应同一个 key 时使用第一个非零值的字段，全部为零值时使用最后一个，位于 nil 嵌入指针中的字段视为不存在，
+// 多个非零值的编码结果不同时通过 bencode.SameKey 返回 bencode.ErrKeyConflict
+func (g *generator) genKey(p *plan, key string) {
+	idx := p.byKey[key]
+	omit := p.fields[idx[0]].omitEmpty
+	if len(idx) == 1 {
+		f := p.fields[idx[0]]
+		g.genField(f, key, omit, reachable(f), "")
+		return
+	}
+
+	g.p("{")
+	g.p("var set []any")
+	for _, i := range idx {
+		f := p.fields[i]
+		g.p("if %s {", strings.Join(append(reachable(f), g.nonZero(f.expr(), f.typ)), " && "))
+		g.p("set = append(set, %s)", addr(f.expr()))
+		g.p("}")
+	}
+	g.p("if err := bencode.SameKey(%q, set...); err != nil {", key)
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+
+	g.p("switch {")
+	for _, i := range idx[:len(idx)-1] {
+		f := p.fields[i]
+		nz := g.nonZero(f.expr(), f.typ)
+		g.p("case %s:", strings.Join(append(reachable(f), nz), " && "))
+		g.genField(f, key, omit, nil, nz)
+	}
+	for j := len(idx) - 1; j >= 0; j-- {
+		f := p.fields[idx[j]]
+		conds := reachable(f)
+		if len(conds) == 0 {
+			g.p("default:")
+			g.genField(f, key, omit, nil, "")
+			break
+		}
+		g.p("case %s:", strings.Join(conds, " && "))
+		g.genField(f, key, omit, nil, "")
+	}
+	g.p("}")
+}
+
+// reachable 返回字段所在的嵌入指针都不为 nil 的条件
+func reachable(f field) []string {
+	var conds []string
+	for _, e := range f.embedded() {
+		conds = append(conds, e+" != nil")
+	}
+	return conds
+}
+
+// genField 在 conds 成立时编码字段，nil 指针和 omitempty 时的空值会被忽略
+// known 为已经成立的条件，不再重复判断
+func (g *generator) genField(f field, key string, omit bool, conds []string, known string) {
+	expr := f.expr()
+	c := ""
+	if _, ok := f.typ.Underlying().(*types.Pointer); ok {
+		c = expr + " != nil"
+	} else if omit {
+		c = nonEmpty(expr, f.typ)
+	}
+	if c != "" && c != known {
+		conds = append(conds, c)
+	}
+
+	if len(conds) > 0 {
+		g.p("if %s {", strings.Join(conds, " && "))
+	}
+	g.p("buf.WriteString(%q)", fmt.Sprintf("%d:%s", len(key), key))
+	if ptr, ok := f.typ.Underlying().(*types.Pointer); ok {
+		// 已经判断过不是 nil
+		g.genEncode("(*"+expr+")", ptr.Elem(), 0)
+	} else {
+		g.genEncode(expr, f.typ, 0)
+	}
+	if len(conds) > 0 {
+		g.p("}")
+	}
+}
+
+// nonEmpty 返回 omitempty 时 expr 需要编码的条件，总是需要编码时返回空字符串
+func nonEmpty(expr string, t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return expr
+		case u.Info()&types.IsString != 0:
+			return expr + ` != ""`
+		case u.Info()&types.IsInteger != 0:
+			return expr + " != 0"
+		}
+	case *types.Slice, *types.Map, *types.Array:
+		return "len(" + expr + ") != 0"
+	case *types.Pointer, *types.Interface:
+		return expr + " != nil"
+	}
+	return ""
+}
+
+// nonZero 返回 expr 不是零值的条件，与 reflect.Value.IsZero 一致
+func (g *generator) nonZero(expr string, t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return expr
+		case u.Info()&types.IsString != 0:
+			return expr + ` != ""`
+		case u.Info()&types.IsInteger != 0:
+			return expr + " != 0"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface, *types.Chan, *types.Signature:
+		return expr + " != nil"
+	case *types.Struct, *types.Array:
+		if plainComparable(t) {
+			return expr + " != (" + g.typeString(t) + "{})"
+		}
+	}
+	g.use("reflect")
+	return "!reflect.ValueOf(" + expr + ").IsZero()"
+}
+
+// plainComparable 判断 t 的零值能否用 == 判断，浮点数的 -0 和包含接口的值与 IsZero 的结果不同
+func plainComparable(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&(types.IsBoolean|types.IsString|types.IsInteger) != 0
+	case *types.Pointer, *types.Chan:
+		return true
+	case *types.Array:
+		return plainComparable(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if !plainComparable(u.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isByte 判断 t 是否是 byte，只有 []byte 和 [N]byte 直接编码，元素为其他 uint8 类型时交给 bencode 包
+func isByte(t types.Type) bool {
+	return types.Identical(t, types.Typ[types.Uint8])
+}
+
+// isUint8Kind 判断 t 的底层类型是否是 uint8，反射解码时这样的 slice 和 array 可以保存字符串
+func isUint8Kind(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+// addr 返回 expr 的地址
+func addr(expr string) string {
+	if strings.HasPrefix(expr, "(*") && strings.HasSuffix(expr, ")") {
+		return expr[2 : len(expr)-1]
+	}
+	return "&" + expr
+}
+
+// unparen 去掉 (*p) 形式的表达式外层的括号，用作函数参数
+func unparen(expr string) string {
+	if strings.HasPrefix(expr, "(*") && strings.HasSuffix(expr, ")") {
+		return expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+// convert 返回将 expr 转换为基本类型 to 的表达式，类型相同时不转换
+func convert(expr string, t types.Type, to types.BasicKind) string {
+	if types.Identical(t, types.Typ[to]) {
+		return expr
+	}
+	return types.Typ[to].Name() + "(" + expr + ")"
+}
+
+// genEncode 生成将 expr 编码到 buf 的语句，expr 必须可以取地址
+func (g *generator) genEncode(expr string, t types.Type, depth int) {
+	if g.generated(t, "MarshalBencodeTo") {
+		g.p("if err := %s.MarshalBencodeTo(buf); err != nil {", expr)
+		g.p("return err")
+		g.p("}")
+		return
+	}
+
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+	default:
+		// 可以取地址时指针接收者的方法同样会被使用
+		if types.Implements(t, marshalerIface) || types.Implements(types.NewPointer(t), marshalerIface) {
+			g.genWrite(expr + ".MarshalBencode()")
+			return
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			g.p("bencode.WriteBool(buf, %s)", convert(unparen(expr), t, types.Bool))
+		case u.Info()&types.IsUnsigned != 0:
+			g.p("bencode.WriteUint(buf, %s)", convert(unparen(expr), t, types.Uint64))
+		case u.Info()&types.IsInteger != 0:
+			g.p("bencode.WriteInt(buf, %s)", convert(unparen(expr), t, types.Int64))
+		case u.Info()&types.IsString != 0:
+			g.p("bencode.WriteString(buf, %s)", convert(unparen(expr), t, types.String))
+		default:
+			g.genWrite("bencode.Marshal(" + addr(expr) + ")")
+		}
+
+	case *types.Slice:
+		switch {
+		case isByte(u.Elem()):
+			g.p("bencode.WriteBytes(buf, %s)", expr)
+		case isUint8Kind(u.Elem()):
+			g.genWrite("bencode.Marshal(" + addr(expr) + ")")
+		default:
+			g.genList(expr, u.Elem(), depth)
+		}
+
+	case *types.Array:
+		switch {
+		case isByte(u.Elem()):
+			g.p("bencode.WriteBytes(buf, %s[:])", expr)
+		case isUint8Kind(u.Elem()):
+			g.genWrite("bencode.Marshal(" + addr(expr) + ")")
+		default:
+			g.genList(expr, u.Elem(), depth)
+		}
+
+	case *types.Pointer:
+		// nil 指针编码为指向类型的零值
+		v := fmt.Sprintf("p%d", depth)
+		g.p("if %s := %s; %s != nil {", v, expr, v)
+		g.genEncode("(*"+v+")", u.Elem(), depth+1)
+		g.p("} else {")
+		g.p("%s := new(%s)", v, g.typeString(u.Elem()))
+		g.genEncode("(*"+v+")", u.Elem(), depth+1)
+		g.p("}")
+
+	default:
+		// map、interface、没有生成方法的结构体等交给 bencode 包
+		g.genWrite("bencode.Marshal(" + addr(expr) + ")")
+	}
+}
+
+// genList 生成编码列表的语句
+func (g *generator) genList(expr string, elem types.Type, depth int) {
+	i := fmt.Sprintf("i%d", depth)
+	g.p("buf.WriteByte('l')")
+	g.p("for %s := range %s {", i, expr)
+	g.genEncode(fmt.Sprintf("%s[%s]", expr, i), elem, depth+1)
+	g.p("}")
+	g.p("buf.WriteByte('e')")
+}
+
+// genWrite 生成调用 call 得到编码结果并写入 buf 的语句
+func (g *generator) genWrite(call string) {
+	g.p("if data, err := %s; err != nil {", call)
+	g.p("return err")
+	g.p("} else {")
+	g.p("buf.Write(data)")
+	g.p("}")
+}
+
+// genUnmarshal 生成 UnmarshalBencode
+func (g *generator) genUnmarshal(name string, p *plan) {
+	g.p("")
+	g.p("// UnmarshalBencode 实现 bencode.Unmarshaler")
+	g.p("func (x *%s) UnmarshalBencode(data []byte) error {", name)
+	g.p("return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)")
+	g.p("}")
+
+	// 每个带有 required 选项的 key 对应 found 中的一个位置
+	slots := map[string]int{}
+	for _, f := range p.fields {
+		if _, ok := slots[f.key]; f.required && !ok {
+			slots[f.key] = len(slots)
+		}
+	}
+
+	g.p("")
+	g.p("// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler")
+	g.p("func (x *%s) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {", name)
+	g.p("if tok.Kind != bencode.TokenDict {")
+	g.p("return bencode.TypeError(tok, x)")
+	g.p("}")
+	if len(slots) > 0 {
+		g.p("var found [%d]bool", len(slots))
+	}
+	g.p("for {")
+	g.p("key, err := s.Next()")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("if key.Kind == bencode.TokenEnd {")
+	g.p("break")
+	g.p("}")
+	g.p("val, err := s.Next()")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("switch string(key.Value) {")
+	for _, key := range p.keys {
+		g.p("case %q:", key)
+		idx := p.byKey[key]
+		if len(idx) == 1 {
+			f := p.fields[idx[0]]
+			g.genAlloc(f)
+			g.genDecode("s", "val", f.expr(), f.typ, 0)
+		} else {
+			g.genValues(p, idx)
+		}
+		if i, ok := slots[key]; ok {
+			g.p("found[%d] = true", i)
+		}
+	}
+	g.p("default:")
+	g.p("_, err = s.Skip(val)")
+	g.p("}")
+	g.p("if err != nil {")
+	g.p("return bencode.WithField(err, string(key.Value))")
+	g.p("}")
+	g.p("}")
+
+	// 按字段顺序检查，与反射解码的错误信息相同
+	for _, f := range p.fields {
+		if !f.required {
+			continue
+		}
+		g.use("fmt")
+		g.p("if !found[%d] {", slots[f.key])
+		g.p("return fmt.Errorf(\"%%w: %%q (field %s.%s)\", bencode.ErrMissingKey, %q)", name, f.name, f.key)
+		g.p("}")
+	}
+	g.p("return nil")
+	g.p("}")
+}
+
+// genAlloc 初始化字段所在的 nil 嵌入指针
+func (g *generator) genAlloc(f field) {
+	for i, s := range f.path[:len(f.path)-1] {
+		if !s.ptr {
+			continue
+		}
+		e := field{path: f.path[:i+1]}.expr()
+		g.p("if %s == nil {", e)
+		g.p("%s = new(%s)", e, g.typeString(s.elem))
+		g.p("}")
+	}
+}
+
+// genValues 将同一个值分别解析到对应同一个 key 的每个字段
+func (g *generator) genValues(p *plan, idx []int) {
+	g.p("err = func() error {")
+	g.p("raw, err := s.Skip(val)")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	for _, i := range idx {
+		f := p.fields[i]
+		g.p("{")
+		g.p("sub := s.Sub(val, raw)")
+		g.p("tok, err := sub.Next()")
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.genAlloc(f)
+		g.genDecode("sub", "tok", f.expr(), f.typ, 0)
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("}")
+	}
+	g.p("return nil")
+	g.p("}()")
+}
+
+// genDecode 生成将 tv 开始的值解析到 expr 的语句，结果保存在 err 中，expr 必须可以取地址
+func (g *generator) genDecode(sv, tv, expr string, t types.Type, depth int) {
+	if g.generated(t, "UnmarshalBencodeFrom") {
+		g.p("err = %s.UnmarshalBencodeFrom(%s, %s)", expr, sv, tv)
+		return
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		// nil 指针会被初始化，指针类型实现 Unmarshaler 时同样由下面的分支处理
+		g.p("if %s == nil {", expr)
+		g.p("%s = new(%s)", expr, g.typeString(u.Elem()))
+		g.p("}")
+		g.genDecode(sv, tv, "(*"+expr+")", u.Elem(), depth)
+		return
+
+	case *types.Interface:
+	default:
+		if types.Implements(types.NewPointer(t), unmarshalerIface) {
+			g.p("var raw []byte")
+			g.p("raw, err = %s.Skip(%s)", sv, tv)
+			g.p("if err == nil {")
+			g.p("err = %s.UnmarshalBencode(raw)", expr)
+			g.p("}")
+			return
+		}
+	}
+
+	if u, ok := t.Underlying().(*types.Slice); ok && !isUint8Kind(u.Elem()) {
+		g.genSlice(sv, tv, expr, t, u.Elem(), depth)
+		return
+	}
+	// 基本类型直接保存，其余类型交给 bencode 包
+	g.p("err = bencode.DecodeValue(%s, %s, %s)", sv, tv, addr(expr))
+}
+
+// genSlice 生成解析列表到 slice 的语句，出错时不修改 expr
+func (g *generator) genSlice(sv, tv, expr string, t, elem types.Type, depth int) {
+	g.use("strconv")
+	v := fmt.Sprintf("v%d", depth)
+	zero := fmt.Sprintf("zero%d", depth)
+	i := fmt.Sprintf("i%d", depth)
+	el := fmt.Sprintf("el%d", depth)
+
+	g.p("err = func() error {")
+	g.p("if %s.Kind != bencode.TokenList {", tv)
+	g.p("return bencode.TypeError(%s, %s)", tv, addr(expr))
+	g.p("}")
+	g.p("%s := %s{}", v, g.typeString(t))
+	g.p("var %s %s", zero, g.typeString(elem))
+	g.p("for %s := 0; ; %s++ {", i, i)
+	g.p("%s, err := %s.Next()", el, sv)
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("if %s.Kind == bencode.TokenEnd {", el)
+	g.p("break")
+	g.p("}")
+	g.p("%s = append(%s, %s)", v, v, zero)
+	g.genDecode(sv, el, fmt.Sprintf("%s[%s]", v, i), elem, depth+1)
+	g.p("if err != nil {")
+	g.p("return bencode.WithField(err, \"[\"+strconv.Itoa(%s)+\"]\")", i)
+	g.p("}")
+	g.p("}")
+	g.p("%s = %s", expr, v)
+	g.p("return nil")
+	g.p("}()")
+}
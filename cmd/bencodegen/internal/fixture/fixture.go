@@ -0,0 +1,167 @@
+// Package fixture 包含 bencodegen 需要处理的各种字段，测试生成的代码与反射实现的编解码结果完全相同
+package fixture
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+//go:generate go run ../.. -type Basic,Lists,Item,Embed,Dup,Other -output fixture_bencode.go
+
+type (
+	Port uint16
+	Name string
+	Flag bool
+	Byte uint8
+)
+
+// Basic 基本类型和指向基本类型的指针
+type Basic struct {
+	I       int    `bencode:"i"`
+	I8      int8   `bencode:"i8,omitempty"`
+	I16     int16  // 没有标签时使用字段名
+	I32     int32  `bencode:"i32"`
+	I64     int64  `bencode:"i64,required"`
+	U       uint   `bencode:"u,omitempty"`
+	U8      uint8  `bencode:"u8"`
+	U16     uint16 `bencode:"u16"`
+	U32     uint32 `bencode:"u32"`
+	U64     uint64 `bencode:"u64"`
+	B       bool   `bencode:"b"`
+	OB      bool   `bencode:"ob,omitempty"`
+	S       string `bencode:"s,required"`
+	Port    Port   `bencode:"port,omitempty"`
+	Name    Name   `bencode:"name"`
+	Flag    Flag   `bencode:"flag"`
+	Bytes   []byte `bencode:"bytes,omitempty"`
+	Bytes2  []Byte `bencode:"bytes2"`
+	Hash    [4]byte
+	PI      *int    `bencode:"pi"`
+	PS      *string `bencode:"ps,omitempty"`
+	Ignored string  `bencode:"-"`
+	skipped int
+}
+
+// Lists 列表和数组
+type Lists struct {
+	Ints     []int      `bencode:"ints"`
+	Nested   [][]string `bencode:"nested,omitempty"`
+	Items    []Item     `bencode:"items"`
+	ItemPtrs []*Item    `bencode:"item ptrs,omitempty"`
+	Arr      [2]int     `bencode:"arr"`
+	Hashes   [][4]byte  `bencode:"hashes,omitempty"`
+	PList    *[]string  `bencode:"plist"`
+	Ports    []Port     `bencode:"ports,omitempty"`
+	PItem    *Item      `bencode:"pitem"`
+}
+
+// Item 列表中的结构体
+type Item struct {
+	ID   int64    `bencode:"id,required"`
+	Tags []string `bencode:"tags,omitempty"`
+}
+
+type Inner struct {
+	A string `bencode:"a"`
+	B int    `bencode:"b,omitempty"`
+}
+
+type Deep struct {
+	C string `bencode:"c"`
+	Inner
+}
+
+// Embed 嵌入的结构体和结构体指针
+type Embed struct {
+	*Inner
+	Deep
+	Tagged Inner `bencode:"tagged"`
+	X      int   `bencode:"x"`
+}
+
+// Dup 多个字段对应同一个 key
+type Dup struct {
+	Raw  bencode.RawMessage `bencode:"v"`
+	Val  Item               `bencode:"v"`
+	N1   int                `bencode:"n,omitempty"`
+	N2   int64              `bencode:"n"`
+	S1   *string            `bencode:"s"`
+	S2   string             `bencode:"s"`
+	P1   Point              `bencode:"p,omitempty"`
+	P2   Point              `bencode:"p"`
+	Any1 any                `bencode:"any"`
+	Any2 any                `bencode:"any"`
+}
+
+// Point 没有生成方法的结构体
+type Point struct {
+	X, Y int
+}
+
+// Other 交给 bencode 包处理或者实现了 Marshaler 的字段
+type Other struct {
+	M   map[string]int `bencode:"m,omitempty"`
+	Any any            `bencode:"any,omitempty"`
+	C   Compact        `bencode:"c"`
+	PC  *Compact       `bencode:"pc"`
+	V   Version        `bencode:"v"`
+	Pt  Point          `bencode:"pt"`
+	F   float64        `bencode:"f,omitempty"`
+	Pts [2]Point       `bencode:"pts"`
+}
+
+// Compact 使用指针接收者实现 Marshaler，编码为逗号分隔的字符串
+type Compact []int
+
+// MarshalBencode 实现 bencode.Marshaler
+func (c *Compact) MarshalBencode() ([]byte, error) {
+	s := make([]string, len(*c))
+	for i, n := range *c {
+		s[i] = strconv.Itoa(n)
+	}
+	return bencode.Marshal(strings.Join(s, ","))
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (c *Compact) UnmarshalBencode(data []byte) error {
+	var s string
+	err := bencode.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	*c = Compact{}
+	for _, f := range strings.Split(s, ",") {
+		if f == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return err
+		}
+		*c = append(*c, n)
+	}
+	return nil
+}
+
+// Version 使用值接收者实现 Marshaler，编码为整数
+type Version struct {
+	Major, Minor uint8
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (v Version) MarshalBencode() ([]byte, error) {
+	return bencode.Marshal(int(v.Major)<<8 | int(v.Minor))
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (v *Version) UnmarshalBencode(data []byte) error {
+	var n uint16
+	err := bencode.Unmarshal(data, &n)
+	if err != nil {
+		return err
+	}
+	v.Major, v.Minor = uint8(n>>8), uint8(n)
+	return nil
+}
@@ -0,0 +1,1156 @@
+// Code generated by "bencodegen -type Basic,Lists,Item,Embed,Dup,Other"; DO NOT EDIT.
+
+package fixture
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Basic) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Basic) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("4:Hash")
+	bencode.WriteBytes(buf, x.Hash[:])
+	buf.WriteString("3:I16")
+	bencode.WriteInt(buf, int64(x.I16))
+	buf.WriteString("1:b")
+	bencode.WriteBool(buf, x.B)
+	if len(x.Bytes) != 0 {
+		buf.WriteString("5:bytes")
+		bencode.WriteBytes(buf, x.Bytes)
+	}
+	buf.WriteString("6:bytes2")
+	if data, err := bencode.Marshal(&x.Bytes2); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteString("4:flag")
+	bencode.WriteBool(buf, bool(x.Flag))
+	buf.WriteString("1:i")
+	bencode.WriteInt(buf, int64(x.I))
+	buf.WriteString("3:i32")
+	bencode.WriteInt(buf, int64(x.I32))
+	buf.WriteString("3:i64")
+	bencode.WriteInt(buf, x.I64)
+	if x.I8 != 0 {
+		buf.WriteString("2:i8")
+		bencode.WriteInt(buf, int64(x.I8))
+	}
+	buf.WriteString("4:name")
+	bencode.WriteString(buf, string(x.Name))
+	if x.OB {
+		buf.WriteString("2:ob")
+		bencode.WriteBool(buf, x.OB)
+	}
+	if x.PI != nil {
+		buf.WriteString("2:pi")
+		bencode.WriteInt(buf, int64(*x.PI))
+	}
+	if x.Port != 0 {
+		buf.WriteString("4:port")
+		bencode.WriteUint(buf, uint64(x.Port))
+	}
+	if x.PS != nil {
+		buf.WriteString("2:ps")
+		bencode.WriteString(buf, *x.PS)
+	}
+	buf.WriteString("1:s")
+	bencode.WriteString(buf, x.S)
+	if x.U != 0 {
+		buf.WriteString("1:u")
+		bencode.WriteUint(buf, uint64(x.U))
+	}
+	buf.WriteString("3:u16")
+	bencode.WriteUint(buf, uint64(x.U16))
+	buf.WriteString("3:u32")
+	bencode.WriteUint(buf, uint64(x.U32))
+	buf.WriteString("3:u64")
+	bencode.WriteUint(buf, x.U64)
+	buf.WriteString("2:u8")
+	bencode.WriteUint(buf, uint64(x.U8))
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Basic) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Basic) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	var found [2]bool
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "Hash":
+			err = bencode.DecodeValue(s, val, &x.Hash)
+		case "I16":
+			err = bencode.DecodeValue(s, val, &x.I16)
+		case "b":
+			err = bencode.DecodeValue(s, val, &x.B)
+		case "bytes":
+			err = bencode.DecodeValue(s, val, &x.Bytes)
+		case "bytes2":
+			err = bencode.DecodeValue(s, val, &x.Bytes2)
+		case "flag":
+			err = bencode.DecodeValue(s, val, &x.Flag)
+		case "i":
+			err = bencode.DecodeValue(s, val, &x.I)
+		case "i32":
+			err = bencode.DecodeValue(s, val, &x.I32)
+		case "i64":
+			err = bencode.DecodeValue(s, val, &x.I64)
+			found[0] = true
+		case "i8":
+			err = bencode.DecodeValue(s, val, &x.I8)
+		case "name":
+			err = bencode.DecodeValue(s, val, &x.Name)
+		case "ob":
+			err = bencode.DecodeValue(s, val, &x.OB)
+		case "pi":
+			if x.PI == nil {
+				x.PI = new(int)
+			}
+			err = bencode.DecodeValue(s, val, x.PI)
+		case "port":
+			err = bencode.DecodeValue(s, val, &x.Port)
+		case "ps":
+			if x.PS == nil {
+				x.PS = new(string)
+			}
+			err = bencode.DecodeValue(s, val, x.PS)
+		case "s":
+			err = bencode.DecodeValue(s, val, &x.S)
+			found[1] = true
+		case "u":
+			err = bencode.DecodeValue(s, val, &x.U)
+		case "u16":
+			err = bencode.DecodeValue(s, val, &x.U16)
+		case "u32":
+			err = bencode.DecodeValue(s, val, &x.U32)
+		case "u64":
+			err = bencode.DecodeValue(s, val, &x.U64)
+		case "u8":
+			err = bencode.DecodeValue(s, val, &x.U8)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	if !found[0] {
+		return fmt.Errorf("%w: %q (field Basic.I64)", bencode.ErrMissingKey, "i64")
+	}
+	if !found[1] {
+		return fmt.Errorf("%w: %q (field Basic.S)", bencode.ErrMissingKey, "s")
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Lists) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Lists) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("3:arr")
+	buf.WriteByte('l')
+	for i0 := range x.Arr {
+		bencode.WriteInt(buf, int64(x.Arr[i0]))
+	}
+	buf.WriteByte('e')
+	if len(x.Hashes) != 0 {
+		buf.WriteString("6:hashes")
+		buf.WriteByte('l')
+		for i0 := range x.Hashes {
+			bencode.WriteBytes(buf, x.Hashes[i0][:])
+		}
+		buf.WriteByte('e')
+	}
+	buf.WriteString("4:ints")
+	buf.WriteByte('l')
+	for i0 := range x.Ints {
+		bencode.WriteInt(buf, int64(x.Ints[i0]))
+	}
+	buf.WriteByte('e')
+	if len(x.ItemPtrs) != 0 {
+		buf.WriteString("9:item ptrs")
+		buf.WriteByte('l')
+		for i0 := range x.ItemPtrs {
+			if p1 := x.ItemPtrs[i0]; p1 != nil {
+				if err := (*p1).MarshalBencodeTo(buf); err != nil {
+					return err
+				}
+			} else {
+				p1 := new(Item)
+				if err := (*p1).MarshalBencodeTo(buf); err != nil {
+					return err
+				}
+			}
+		}
+		buf.WriteByte('e')
+	}
+	buf.WriteString("5:items")
+	buf.WriteByte('l')
+	for i0 := range x.Items {
+		if err := x.Items[i0].MarshalBencodeTo(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('e')
+	if len(x.Nested) != 0 {
+		buf.WriteString("6:nested")
+		buf.WriteByte('l')
+		for i0 := range x.Nested {
+			buf.WriteByte('l')
+			for i1 := range x.Nested[i0] {
+				bencode.WriteString(buf, x.Nested[i0][i1])
+			}
+			buf.WriteByte('e')
+		}
+		buf.WriteByte('e')
+	}
+	if x.PItem != nil {
+		buf.WriteString("5:pitem")
+		if err := (*x.PItem).MarshalBencodeTo(buf); err != nil {
+			return err
+		}
+	}
+	if x.PList != nil {
+		buf.WriteString("5:plist")
+		buf.WriteByte('l')
+		for i0 := range *x.PList {
+			bencode.WriteString(buf, (*x.PList)[i0])
+		}
+		buf.WriteByte('e')
+	}
+	if len(x.Ports) != 0 {
+		buf.WriteString("5:ports")
+		buf.WriteByte('l')
+		for i0 := range x.Ports {
+			bencode.WriteUint(buf, uint64(x.Ports[i0]))
+		}
+		buf.WriteByte('e')
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Lists) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Lists) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "arr":
+			err = bencode.DecodeValue(s, val, &x.Arr)
+		case "hashes":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Hashes)
+				}
+				v0 := [][4]byte{}
+				var zero0 [4]byte
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Hashes = v0
+				return nil
+			}()
+		case "ints":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Ints)
+				}
+				v0 := []int{}
+				var zero0 int
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Ints = v0
+				return nil
+			}()
+		case "item ptrs":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.ItemPtrs)
+				}
+				v0 := []*Item{}
+				var zero0 *Item
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					if v0[i0] == nil {
+						v0[i0] = new(Item)
+					}
+					err = (*v0[i0]).UnmarshalBencodeFrom(s, el0)
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.ItemPtrs = v0
+				return nil
+			}()
+		case "items":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Items)
+				}
+				v0 := []Item{}
+				var zero0 Item
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = v0[i0].UnmarshalBencodeFrom(s, el0)
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Items = v0
+				return nil
+			}()
+		case "nested":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Nested)
+				}
+				v0 := [][]string{}
+				var zero0 []string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = func() error {
+						if el0.Kind != bencode.TokenList {
+							return bencode.TypeError(el0, &v0[i0])
+						}
+						v1 := []string{}
+						var zero1 string
+						for i1 := 0; ; i1++ {
+							el1, err := s.Next()
+							if err != nil {
+								return err
+							}
+							if el1.Kind == bencode.TokenEnd {
+								break
+							}
+							v1 = append(v1, zero1)
+							err = bencode.DecodeValue(s, el1, &v1[i1])
+							if err != nil {
+								return bencode.WithField(err, "["+strconv.Itoa(i1)+"]")
+							}
+						}
+						v0[i0] = v1
+						return nil
+					}()
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Nested = v0
+				return nil
+			}()
+		case "pitem":
+			if x.PItem == nil {
+				x.PItem = new(Item)
+			}
+			err = (*x.PItem).UnmarshalBencodeFrom(s, val)
+		case "plist":
+			if x.PList == nil {
+				x.PList = new([]string)
+			}
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, x.PList)
+				}
+				v0 := []string{}
+				var zero0 string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				(*x.PList) = v0
+				return nil
+			}()
+		case "ports":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Ports)
+				}
+				v0 := []Port{}
+				var zero0 Port
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Ports = v0
+				return nil
+			}()
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Item) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Item) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("2:id")
+	bencode.WriteInt(buf, x.ID)
+	if len(x.Tags) != 0 {
+		buf.WriteString("4:tags")
+		buf.WriteByte('l')
+		for i0 := range x.Tags {
+			bencode.WriteString(buf, x.Tags[i0])
+		}
+		buf.WriteByte('e')
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Item) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Item) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	var found [1]bool
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "id":
+			err = bencode.DecodeValue(s, val, &x.ID)
+			found[0] = true
+		case "tags":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Tags)
+				}
+				v0 := []string{}
+				var zero0 string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Tags = v0
+				return nil
+			}()
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	if !found[0] {
+		return fmt.Errorf("%w: %q (field Item.ID)", bencode.ErrMissingKey, "id")
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Embed) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Embed) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	if x.Inner != nil {
+		buf.WriteString("1:a")
+		bencode.WriteString(buf, x.Inner.A)
+	}
+	if x.Inner != nil && x.Inner.B != 0 {
+		buf.WriteString("1:b")
+		bencode.WriteInt(buf, int64(x.Inner.B))
+	}
+	buf.WriteString("1:c")
+	bencode.WriteString(buf, x.Deep.C)
+	buf.WriteString("6:tagged")
+	if data, err := bencode.Marshal(&x.Tagged); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteString("1:x")
+	bencode.WriteInt(buf, int64(x.X))
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Embed) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Embed) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "a":
+			if x.Inner == nil {
+				x.Inner = new(Inner)
+			}
+			err = bencode.DecodeValue(s, val, &x.Inner.A)
+		case "b":
+			if x.Inner == nil {
+				x.Inner = new(Inner)
+			}
+			err = bencode.DecodeValue(s, val, &x.Inner.B)
+		case "c":
+			err = bencode.DecodeValue(s, val, &x.Deep.C)
+		case "tagged":
+			err = bencode.DecodeValue(s, val, &x.Tagged)
+		case "x":
+			err = bencode.DecodeValue(s, val, &x.X)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Dup) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Dup) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	{
+		var set []any
+		if x.Any1 != nil {
+			set = append(set, &x.Any1)
+		}
+		if x.Any2 != nil {
+			set = append(set, &x.Any2)
+		}
+		if err := bencode.SameKey("any", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.Any1 != nil:
+		buf.WriteString("3:any")
+		if data, err := bencode.Marshal(&x.Any1); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	default:
+		buf.WriteString("3:any")
+		if data, err := bencode.Marshal(&x.Any2); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	{
+		var set []any
+		if x.N1 != 0 {
+			set = append(set, &x.N1)
+		}
+		if x.N2 != 0 {
+			set = append(set, &x.N2)
+		}
+		if err := bencode.SameKey("n", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.N1 != 0:
+		buf.WriteString("1:n")
+		bencode.WriteInt(buf, int64(x.N1))
+	default:
+		if x.N2 != 0 {
+			buf.WriteString("1:n")
+			bencode.WriteInt(buf, x.N2)
+		}
+	}
+	{
+		var set []any
+		if x.P1 != (Point{}) {
+			set = append(set, &x.P1)
+		}
+		if x.P2 != (Point{}) {
+			set = append(set, &x.P2)
+		}
+		if err := bencode.SameKey("p", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.P1 != (Point{}):
+		buf.WriteString("1:p")
+		if data, err := bencode.Marshal(&x.P1); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	default:
+		buf.WriteString("1:p")
+		if data, err := bencode.Marshal(&x.P2); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	{
+		var set []any
+		if x.S1 != nil {
+			set = append(set, &x.S1)
+		}
+		if x.S2 != "" {
+			set = append(set, &x.S2)
+		}
+		if err := bencode.SameKey("s", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.S1 != nil:
+		buf.WriteString("1:s")
+		bencode.WriteString(buf, *x.S1)
+	default:
+		buf.WriteString("1:s")
+		bencode.WriteString(buf, x.S2)
+	}
+	{
+		var set []any
+		if x.Raw != nil {
+			set = append(set, &x.Raw)
+		}
+		if !reflect.ValueOf(x.Val).IsZero() {
+			set = append(set, &x.Val)
+		}
+		if err := bencode.SameKey("v", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.Raw != nil:
+		buf.WriteString("1:v")
+		if data, err := x.Raw.MarshalBencode(); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	default:
+		buf.WriteString("1:v")
+		if err := x.Val.MarshalBencodeTo(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Dup) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Dup) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "any":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.Any1)
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.Any2)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		case "n":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.N1)
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.N2)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		case "p":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.P1)
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.P2)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		case "s":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					if x.S1 == nil {
+						x.S1 = new(string)
+					}
+					err = bencode.DecodeValue(sub, tok, x.S1)
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = bencode.DecodeValue(sub, tok, &x.S2)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		case "v":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					var raw []byte
+					raw, err = sub.Skip(tok)
+					if err == nil {
+						err = x.Raw.UnmarshalBencode(raw)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = x.Val.UnmarshalBencodeFrom(sub, tok)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Other) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Other) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	if x.Any != nil {
+		buf.WriteString("3:any")
+		if data, err := bencode.Marshal(&x.Any); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	buf.WriteString("1:c")
+	if data, err := x.C.MarshalBencode(); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteString("1:f")
+	if data, err := bencode.Marshal(&x.F); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	if len(x.M) != 0 {
+		buf.WriteString("1:m")
+		if data, err := bencode.Marshal(&x.M); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	if x.PC != nil {
+		buf.WriteString("2:pc")
+		if data, err := (*x.PC).MarshalBencode(); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	buf.WriteString("2:pt")
+	if data, err := bencode.Marshal(&x.Pt); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteString("3:pts")
+	buf.WriteByte('l')
+	for i0 := range x.Pts {
+		if data, err := bencode.Marshal(&x.Pts[i0]); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	}
+	buf.WriteByte('e')
+	buf.WriteString("1:v")
+	if data, err := x.V.MarshalBencode(); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Other) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Other) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "any":
+			err = bencode.DecodeValue(s, val, &x.Any)
+		case "c":
+			var raw []byte
+			raw, err = s.Skip(val)
+			if err == nil {
+				err = x.C.UnmarshalBencode(raw)
+			}
+		case "f":
+			err = bencode.DecodeValue(s, val, &x.F)
+		case "m":
+			err = bencode.DecodeValue(s, val, &x.M)
+		case "pc":
+			if x.PC == nil {
+				x.PC = new(Compact)
+			}
+			var raw []byte
+			raw, err = s.Skip(val)
+			if err == nil {
+				err = (*x.PC).UnmarshalBencode(raw)
+			}
+		case "pt":
+			err = bencode.DecodeValue(s, val, &x.Pt)
+		case "pts":
+			err = bencode.DecodeValue(s, val, &x.Pts)
+		case "v":
+			var raw []byte
+			raw, err = s.Skip(val)
+			if err == nil {
+				err = x.V.UnmarshalBencode(raw)
+			}
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
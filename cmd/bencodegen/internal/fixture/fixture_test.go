@@ -0,0 +1,251 @@
+package fixture
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// 与生成方法的类型具有相同字段但没有方法的类型，使用反射编解码
+type (
+	plainBasic Basic
+	plainLists Lists
+	plainItem  Item
+	plainEmbed Embed
+	plainDup   Dup
+	plainOther Other
+)
+
+func TestBasic(t *testing.T) {
+	check(t, func(x *Basic) *plainBasic { return (*plainBasic)(x) })
+}
+
+func TestLists(t *testing.T) {
+	check(t, func(x *Lists) *plainLists { return (*plainLists)(x) })
+}
+
+func TestItem(t *testing.T) {
+	check(t, func(x *Item) *plainItem { return (*plainItem)(x) })
+}
+
+func TestEmbed(t *testing.T) {
+	check(t, func(x *Embed) *plainEmbed { return (*plainEmbed)(x) })
+}
+
+func TestDup(t *testing.T) {
+	check(t, func(x *Dup) *plainDup { return (*plainDup)(x) })
+}
+
+func TestOther(t *testing.T) {
+	check(t, func(x *Other) *plainOther { return (*plainOther)(x) })
+}
+
+// check 使用随机的值比较生成的方法和反射的编码结果，再用编码结果及其随机修改后的数据比较解码结果
+func check[T, P any](t *testing.T, plain func(*T) *P) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		var x T
+		randomize(rnd, reflect.ValueOf(&x).Elem())
+
+		// 反射编码可以取地址的值时同样会使用指针接收者的 MarshalBencode
+		got, gerr := bencode.Marshal(x)
+		want, werr := bencode.Marshal(plain(&x))
+		if !sameError(gerr, werr) || !bytes.Equal(got, want) {
+			t.Fatalf("Marshal(%+v):\ngot  %q, %v\nwant %q, %v", x, got, gerr, want, werr)
+		}
+		if werr != nil {
+			continue
+		}
+
+		checkDecode(t, want, plain)
+		for j := 0; j < 10; j++ {
+			checkDecode(t, mutate(rnd, want), plain)
+		}
+	}
+}
+
+// checkDecode 比较生成的方法和反射的解码结果
+func checkDecode[T, P any](t *testing.T, data []byte, plain func(*T) *P) {
+	t.Helper()
+	var x T
+	var p P
+	gerr := bencode.Unmarshal(data, &x)
+	werr := bencode.Unmarshal(data, &p)
+	if !sameError(gerr, werr) {
+		t.Fatalf("Unmarshal(%q):\ngot  %v\nwant %v", data, gerr, werr)
+	}
+	if werr == nil && !reflect.DeepEqual(plain(&x), &p) {
+		t.Fatalf("Unmarshal(%q):\ngot  %+v\nwant %+v", data, x, p)
+	}
+}
+
+// sameError 判断两个错误是否相同，错误信息中的类型名去掉 plain 前缀
+func sameError(got, want error) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return got.Error() == strings.ReplaceAll(want.Error(), "plain", "")
+}
+
+// mutate 随机修改、插入或删除 data 中的一个字节
+func mutate(rnd *rand.Rand, data []byte) []byte {
+	const chars = "ilde0123456789:-x"
+	out := append([]byte{}, data...)
+	i := rnd.Intn(len(out))
+	c := chars[rnd.Intn(len(chars))]
+	switch rnd.Intn(3) {
+	case 0:
+		out[i] = c
+	case 1:
+		out = append(out[:i], append([]byte{c}, out[i:]...)...)
+	default:
+		out = append(out[:i], out[i+1:]...)
+	}
+	return out
+}
+
+// randomize 为 v 中所有可以设置的字段生成随机的值，零值和 nil 出现的概率较高
+func randomize(rnd *rand.Rand, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(rnd.Intn(2) == 0)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch rnd.Intn(3) {
+		case 0:
+			v.SetInt(0)
+		case 1:
+			v.SetInt(rnd.Int63n(200) - 100)
+		default:
+			v.SetInt(int64(rnd.Uint64()))
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch rnd.Intn(3) {
+		case 0:
+			v.SetUint(0)
+		case 1:
+			v.SetUint(uint64(rnd.Intn(300)))
+		default:
+			v.SetUint(rnd.Uint64())
+		}
+
+	case reflect.Float64:
+		// 非零的浮点数无法编码，两边应该返回相同的错误
+		if rnd.Intn(10) == 0 {
+			v.SetFloat(rnd.Float64())
+		}
+
+	case reflect.String:
+		b := make([]byte, rnd.Intn(6))
+		for i := range b {
+			b[i] = "abc,1\x00\xff"[rnd.Intn(7)]
+		}
+		v.SetString(string(b))
+
+	case reflect.Slice:
+		if rnd.Intn(4) == 0 {
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), rnd.Intn(4), rnd.Intn(4)+4)
+		for i := 0; i < s.Len(); i++ {
+			randomize(rnd, s.Index(i))
+		}
+		v.Set(s)
+
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			randomize(rnd, v.Index(i))
+		}
+
+	case reflect.Map:
+		if rnd.Intn(3) == 0 {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		for i := rnd.Intn(3); i > 0; i-- {
+			k := reflect.New(v.Type().Key()).Elem()
+			randomize(rnd, k)
+			e := reflect.New(v.Type().Elem()).Elem()
+			randomize(rnd, e)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+
+	case reflect.Pointer:
+		if rnd.Intn(3) == 0 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		randomize(rnd, p.Elem())
+		v.Set(p)
+
+	case reflect.Interface:
+		switch rnd.Intn(4) {
+		case 0:
+		case 1:
+			v.Set(reflect.ValueOf(rnd.Int63n(10)))
+		case 2:
+			v.Set(reflect.ValueOf("s"))
+		default:
+			v.Set(reflect.ValueOf([]any{int64(1), "a"}))
+		}
+
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(Version{}) {
+			// Version 只能保存 uint16 范围内的值
+			v.Set(reflect.ValueOf(Version{Major: uint8(rnd.Intn(256)), Minor: uint8(rnd.Intn(256))}))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				randomize(rnd, v.Field(i))
+			}
+		}
+	}
+}
+
+func TestRawMessageDup(t *testing.T) {
+	// RawMessage 与解析后的结构体编码结果相同时正常输出
+	x := Dup{Raw: bencode.RawMessage("d2:idi2ee"), Val: Item{ID: 2}, S2: "s", Any1: int64(1)}
+	got, err := bencode.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d3:anyi1e1:pd1:Xi0e1:Yi0ee1:s1:s1:vd2:idi2eee"
+	if string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// 编码结果不同时返回错误，生成的方法和反射编码一致
+	x.Val.ID = 3
+	for _, v := range []any{x, (*plainDup)(&x)} {
+		_, err = bencode.Marshal(v)
+		if !errors.Is(err, bencode.ErrKeyConflict) || !strings.Contains(err.Error(), `"v"`) {
+			t.Fatalf("%T: got %v, want ErrKeyConflict", v, err)
+		}
+	}
+}
+
+func TestErrorPath(t *testing.T) {
+	var x Lists
+	err := bencode.Unmarshal([]byte("d5:itemsld2:idi1eed2:id1:aeee"), &x)
+	var te *bencode.UnmarshalTypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v, want *UnmarshalTypeError", err)
+	}
+	// 生成的方法共享同一个 Scanner，偏移量与反射解码相同
+	if te.Field != "items[1].id" || te.Offset != 23 {
+		t.Fatalf("got field %q offset %d", te.Field, te.Offset)
+	}
+
+	err = bencode.Unmarshal([]byte("d5:itemsld4:tagsleeee"), &x)
+	if !errors.Is(err, bencode.ErrMissingKey) || !strings.Contains(err.Error(), "Item.ID") {
+		t.Fatalf("got %v, want missing key", err)
+	}
+}
@@ -0,0 +1,126 @@
+// bencodegen 根据结构体的 bencode 标签生成编解码方法，不需要在运行时使用反射，
+// 生成的代码与 bencode.Marshal、bencode.Unmarshal 的结果完全相同
+//
+// 用法：
+//
+//	//go:generate go run github.com/alctny/torrent/cmd/bencodegen -type TrackerResp,RawInfo
+//
+// 每个类型生成四个方法：MarshalBencode、MarshalBencodeTo（bencode.BufferMarshaler）、
+// UnmarshalBencode 和 UnmarshalBencodeFrom（bencode.ScannerUnmarshaler），
+// 生成的类型之间直接调用，共享同一个 bytes.Buffer 和 Scanner
+// 字段类型无法直接处理时（例如 map、interface、没有生成方法的结构体）仍然交给 bencode 包处理
+//
+// 与反射的区别：实现了指针接收者 Marshaler 的字段总是按照可以取地址的情况编码；
+// 生成的方法与手写的方法一样，会被嵌入了该类型的结构体继承
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "逗号分隔的类型名，必须提供")
+	output    = flag.String("output", "", "输出文件名，默认为 <第一个类型名>_bencode.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: bencodegen -type T1,T2 [-output file] [dir]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bencodegen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	out := *output
+	if out == "" {
+		out = strings.ToLower(names[0]) + "_bencode.go"
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+
+	src, err := generate(dir, filepath.Base(out), names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile(out, src, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate 为 dir 中的包生成 names 中的类型的方法，返回格式化后的源码
+// exclude 为输出文件名，解析时忽略该文件，避免旧的生成代码影响类型检查
+func generate(dir, exclude string, names []string) ([]byte, error) {
+	pkg, err := loadPackage(dir, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGenerator(pkg)
+	for _, name := range names {
+		err := g.addType(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		g.genType(name)
+	}
+	return g.format("-type " + strings.Join(names, ","))
+}
+
+// loadPackage 解析并检查 dir 中的包，忽略 exclude 文件和测试文件
+func loadPackage(dir, exclude string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		if name == exclude {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	// 包中可能引用了尚未生成的方法，只有找不到类型时才会失败
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(bp.Name, fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("cannot type-check package in %s", dir)
+	}
+	return pkg, nil
+}
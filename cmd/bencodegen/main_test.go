@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUpToDate 检查仓库中生成的代码与 go:generate 指令的结果相同
+func TestUpToDate(t *testing.T) {
+	for _, dir := range []string{"internal/fixture", "../../torrent"} {
+		names, output := directive(t, dir)
+		got, err := generate(dir, output, names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join(dir, output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate", filepath.Join(dir, output))
+		}
+	}
+}
+
+// directive 读取 dir 中调用 bencodegen 的 go:generate 指令的参数
+func directive(t *testing.T, dir string) ([]string, string) {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line, ok := strings.CutPrefix(sc.Text(), "//go:generate go run ")
+			if !ok {
+				continue
+			}
+			fs := flag.NewFlagSet("bencodegen", flag.ContinueOnError)
+			types := fs.String("type", "", "")
+			output := fs.String("output", "", "")
+			err := fs.Parse(strings.Fields(line)[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return strings.Split(*types, ","), *output
+		}
+	}
+	t.Fatalf("no go:generate directive in %s", dir)
+	return nil, ""
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, name := range []string{"Missing", "Port", "Compact", "Version"} {
+		_, err := generate("internal/fixture", "fixture_bencode.go", []string{name})
+		if err == nil {
+			t.Errorf("generate %s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,674 @@
+// Code generated by "bencodegen -type RawTorrent,RawInfo,RawFile,Node,TrackerResp"; DO NOT EDIT.
+
+package torrent
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x RawTorrent) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *RawTorrent) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	if x.Anonunce != "" {
+		buf.WriteString("8:announce")
+		bencode.WriteString(buf, x.Anonunce)
+	}
+	if len(x.AnnounceList) != 0 {
+		buf.WriteString("13:announce-list")
+		buf.WriteByte('l')
+		for i0 := range x.AnnounceList {
+			buf.WriteByte('l')
+			for i1 := range x.AnnounceList[i0] {
+				bencode.WriteString(buf, x.AnnounceList[i0][i1])
+			}
+			buf.WriteByte('e')
+		}
+		buf.WriteByte('e')
+	}
+	if x.Comment != "" {
+		buf.WriteString("7:comment")
+		bencode.WriteString(buf, x.Comment)
+	}
+	if x.CreateBy != "" {
+		buf.WriteString("10:created by")
+		bencode.WriteString(buf, x.CreateBy)
+	}
+	if x.CreateAt != 0 {
+		buf.WriteString("13:creation date")
+		bencode.WriteInt(buf, x.CreateAt)
+	}
+	if x.Encoding != "" {
+		buf.WriteString("8:encoding")
+		bencode.WriteString(buf, x.Encoding)
+	}
+	if len(x.HttpSeed) != 0 {
+		buf.WriteString("9:httpseeds")
+		buf.WriteByte('l')
+		for i0 := range x.HttpSeed {
+			bencode.WriteString(buf, x.HttpSeed[i0])
+		}
+		buf.WriteByte('e')
+	}
+	{
+		var set []any
+		if x.InfoRaw != nil {
+			set = append(set, &x.InfoRaw)
+		}
+		if !reflect.ValueOf(x.Info).IsZero() {
+			set = append(set, &x.Info)
+		}
+		if err := bencode.SameKey("info", set...); err != nil {
+			return err
+		}
+	}
+	switch {
+	case x.InfoRaw != nil:
+		buf.WriteString("4:info")
+		if data, err := x.InfoRaw.MarshalBencode(); err != nil {
+			return err
+		} else {
+			buf.Write(data)
+		}
+	default:
+		buf.WriteString("4:info")
+		if err := x.Info.MarshalBencodeTo(buf); err != nil {
+			return err
+		}
+	}
+	if len(x.Node) != 0 {
+		buf.WriteString("5:nodes")
+		buf.WriteByte('l')
+		for i0 := range x.Node {
+			buf.WriteByte('l')
+			for i1 := range x.Node[i0] {
+				if data, err := bencode.Marshal(&x.Node[i0][i1]); err != nil {
+					return err
+				} else {
+					buf.Write(data)
+				}
+			}
+			buf.WriteByte('e')
+		}
+		buf.WriteByte('e')
+	}
+	if len(x.UrlList) != 0 {
+		buf.WriteString("8:url-list")
+		buf.WriteByte('l')
+		for i0 := range x.UrlList {
+			bencode.WriteString(buf, x.UrlList[i0])
+		}
+		buf.WriteByte('e')
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *RawTorrent) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *RawTorrent) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	var found [1]bool
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "announce":
+			err = bencode.DecodeValue(s, val, &x.Anonunce)
+		case "announce-list":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.AnnounceList)
+				}
+				v0 := [][]string{}
+				var zero0 []string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = func() error {
+						if el0.Kind != bencode.TokenList {
+							return bencode.TypeError(el0, &v0[i0])
+						}
+						v1 := []string{}
+						var zero1 string
+						for i1 := 0; ; i1++ {
+							el1, err := s.Next()
+							if err != nil {
+								return err
+							}
+							if el1.Kind == bencode.TokenEnd {
+								break
+							}
+							v1 = append(v1, zero1)
+							err = bencode.DecodeValue(s, el1, &v1[i1])
+							if err != nil {
+								return bencode.WithField(err, "["+strconv.Itoa(i1)+"]")
+							}
+						}
+						v0[i0] = v1
+						return nil
+					}()
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.AnnounceList = v0
+				return nil
+			}()
+		case "comment":
+			err = bencode.DecodeValue(s, val, &x.Comment)
+		case "created by":
+			err = bencode.DecodeValue(s, val, &x.CreateBy)
+		case "creation date":
+			err = bencode.DecodeValue(s, val, &x.CreateAt)
+		case "encoding":
+			err = bencode.DecodeValue(s, val, &x.Encoding)
+		case "httpseeds":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.HttpSeed)
+				}
+				v0 := []string{}
+				var zero0 string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.HttpSeed = v0
+				return nil
+			}()
+		case "info":
+			err = func() error {
+				raw, err := s.Skip(val)
+				if err != nil {
+					return err
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					var raw []byte
+					raw, err = sub.Skip(tok)
+					if err == nil {
+						err = x.InfoRaw.UnmarshalBencode(raw)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				{
+					sub := s.Sub(val, raw)
+					tok, err := sub.Next()
+					if err != nil {
+						return err
+					}
+					err = x.Info.UnmarshalBencodeFrom(sub, tok)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+			found[0] = true
+		case "nodes":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Node)
+				}
+				v0 := [][2]any{}
+				var zero0 [2]any
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Node = v0
+				return nil
+			}()
+		case "url-list":
+			err = x.UrlList.UnmarshalBencodeFrom(s, val)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	if !found[0] {
+		return fmt.Errorf("%w: %q (field RawTorrent.Info)", bencode.ErrMissingKey, "info")
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x RawInfo) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *RawInfo) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	if x.Ed2K != "" {
+		buf.WriteString("4:ed2k")
+		bencode.WriteString(buf, x.Ed2K)
+	}
+	if len(x.FileHash) != 0 {
+		buf.WriteString("8:filehash")
+		bencode.WriteBytes(buf, x.FileHash)
+	}
+	if len(x.Files) != 0 {
+		buf.WriteString("5:files")
+		buf.WriteByte('l')
+		for i0 := range x.Files {
+			if err := x.Files[i0].MarshalBencodeTo(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	}
+	if x.Length != nil {
+		buf.WriteString("6:length")
+		bencode.WriteInt(buf, *x.Length)
+	}
+	buf.WriteString("4:name")
+	bencode.WriteString(buf, x.Name)
+	if x.NameUTF8 != "" {
+		buf.WriteString("10:name.utf-8")
+		bencode.WriteString(buf, x.NameUTF8)
+	}
+	buf.WriteString("12:piece length")
+	bencode.WriteInt(buf, x.PieceLength)
+	buf.WriteString("6:pieces")
+	bencode.WriteString(buf, x.Pieces)
+	if x.Pieces6 != "" {
+		buf.WriteString("7:pieces6")
+		bencode.WriteString(buf, x.Pieces6)
+	}
+	if x.Private != nil {
+		buf.WriteString("7:private")
+		bencode.WriteInt(buf, *x.Private)
+	}
+	if x.Source != nil {
+		buf.WriteString("6:source")
+		bencode.WriteString(buf, *x.Source)
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *RawInfo) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *RawInfo) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	var found [3]bool
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "ed2k":
+			err = bencode.DecodeValue(s, val, &x.Ed2K)
+		case "filehash":
+			err = bencode.DecodeValue(s, val, &x.FileHash)
+		case "files":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Files)
+				}
+				v0 := []RawFile{}
+				var zero0 RawFile
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = v0[i0].UnmarshalBencodeFrom(s, el0)
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Files = v0
+				return nil
+			}()
+		case "length":
+			if x.Length == nil {
+				x.Length = new(int64)
+			}
+			err = bencode.DecodeValue(s, val, x.Length)
+		case "name":
+			err = bencode.DecodeValue(s, val, &x.Name)
+			found[0] = true
+		case "name.utf-8":
+			err = bencode.DecodeValue(s, val, &x.NameUTF8)
+		case "piece length":
+			err = bencode.DecodeValue(s, val, &x.PieceLength)
+			found[1] = true
+		case "pieces":
+			err = bencode.DecodeValue(s, val, &x.Pieces)
+			found[2] = true
+		case "pieces6":
+			err = bencode.DecodeValue(s, val, &x.Pieces6)
+		case "private":
+			if x.Private == nil {
+				x.Private = new(int64)
+			}
+			err = bencode.DecodeValue(s, val, x.Private)
+		case "source":
+			if x.Source == nil {
+				x.Source = new(string)
+			}
+			err = bencode.DecodeValue(s, val, x.Source)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	if !found[0] {
+		return fmt.Errorf("%w: %q (field RawInfo.Name)", bencode.ErrMissingKey, "name")
+	}
+	if !found[1] {
+		return fmt.Errorf("%w: %q (field RawInfo.PieceLength)", bencode.ErrMissingKey, "piece length")
+	}
+	if !found[2] {
+		return fmt.Errorf("%w: %q (field RawInfo.Pieces)", bencode.ErrMissingKey, "pieces")
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x RawFile) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *RawFile) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("6:length")
+	bencode.WriteInt(buf, x.Length)
+	buf.WriteString("4:path")
+	buf.WriteByte('l')
+	for i0 := range x.Path {
+		bencode.WriteString(buf, x.Path[i0])
+	}
+	buf.WriteByte('e')
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *RawFile) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *RawFile) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	var found [2]bool
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "length":
+			err = bencode.DecodeValue(s, val, &x.Length)
+			found[0] = true
+		case "path":
+			err = func() error {
+				if val.Kind != bencode.TokenList {
+					return bencode.TypeError(val, &x.Path)
+				}
+				v0 := []string{}
+				var zero0 string
+				for i0 := 0; ; i0++ {
+					el0, err := s.Next()
+					if err != nil {
+						return err
+					}
+					if el0.Kind == bencode.TokenEnd {
+						break
+					}
+					v0 = append(v0, zero0)
+					err = bencode.DecodeValue(s, el0, &v0[i0])
+					if err != nil {
+						return bencode.WithField(err, "["+strconv.Itoa(i0)+"]")
+					}
+				}
+				x.Path = v0
+				return nil
+			}()
+			found[1] = true
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	if !found[0] {
+		return fmt.Errorf("%w: %q (field RawFile.Length)", bencode.ErrMissingKey, "length")
+	}
+	if !found[1] {
+		return fmt.Errorf("%w: %q (field RawFile.Path)", bencode.ErrMissingKey, "path")
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x Node) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *Node) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("2:ip")
+	bencode.WriteString(buf, x.IP)
+	buf.WriteString("4:port")
+	bencode.WriteInt(buf, x.Port)
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *Node) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *Node) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "ip":
+			err = bencode.DecodeValue(s, val, &x.IP)
+		case "port":
+			err = bencode.DecodeValue(s, val, &x.Port)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler
+func (x TrackerResp) MarshalBencode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := x.MarshalBencodeTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBencodeTo 实现 bencode.BufferMarshaler
+func (x *TrackerResp) MarshalBencodeTo(buf *bytes.Buffer) error {
+	buf.WriteByte('d')
+	buf.WriteString("8:interval")
+	bencode.WriteInt(buf, x.Interval)
+	buf.WriteString("12:min interval")
+	bencode.WriteInt(buf, x.MinInterval)
+	buf.WriteString("5:peers")
+	if data, err := x.Peers.MarshalBencode(); err != nil {
+		return err
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteByte('e')
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (x *TrackerResp) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, x.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler
+func (x *TrackerResp) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenDict {
+		return bencode.TypeError(tok, x)
+	}
+	for {
+		key, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == bencode.TokenEnd {
+			break
+		}
+		val, err := s.Next()
+		if err != nil {
+			return err
+		}
+		switch string(key.Value) {
+		case "interval":
+			err = bencode.DecodeValue(s, val, &x.Interval)
+		case "min interval":
+			err = bencode.DecodeValue(s, val, &x.MinInterval)
+		case "peers":
+			err = x.Peers.UnmarshalBencodeFrom(s, val)
+		default:
+			_, err = s.Skip(val)
+		}
+		if err != nil {
+			return bencode.WithField(err, string(key.Value))
+		}
+	}
+	return nil
+}
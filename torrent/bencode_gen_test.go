@@ -0,0 +1,197 @@
+package torrent
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// 与生成方法的类型具有相同字段但没有方法的类型，使用反射编解码
+type (
+	plainRawTorrent  RawTorrent
+	plainRawInfo     RawInfo
+	plainRawFile     RawFile
+	plainNode        Node
+	plainTrackerResp TrackerResp
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var genInfos = []RawInfo{
+	{},
+	{Name: "a", PieceLength: 16384, Pieces: strings.Repeat("\x01", 20), Length: ptr[int64](100)},
+	{
+		Name:        "dir",
+		PieceLength: 1 << 18,
+		Pieces:      strings.Repeat("\xff", 40),
+		Files: []RawFile{
+			{Length: 1, Path: []string{"a", "b.txt"}},
+			{Length: 0, Path: []string{}},
+			{Path: nil},
+		},
+		NameUTF8: "目录",
+		Ed2K:     "ed2k",
+		FileHash: []byte{0, 1, 2},
+		Private:  ptr[int64](0),
+		Source:   ptr(""),
+	},
+}
+
+func TestGeneratedMarshal(t *testing.T) {
+	for _, info := range genInfos {
+		checkMarshal(t, info, (*plainRawInfo)(&info))
+		for _, f := range info.Files {
+			checkMarshal(t, f, (*plainRawFile)(&f))
+		}
+	}
+
+	torrents := []RawTorrent{
+		{},
+		{
+			Anonunce:     "http://tracker/announce?a=1&b=2",
+			AnnounceList: [][]string{{"http://a"}, {"udp://b", "udp://c"}, {}},
+			UrlList:      []string{"http://seed/"},
+			Node:         [][2]any{{"router.example", int64(6881)}},
+			Comment:      "comment",
+			CreateAt:     1700000000,
+			CreateBy:     "torrent",
+			HttpSeed:     []string{},
+			Encoding:     "UTF-8",
+			Info:         genInfos[2],
+		},
+		// InfoRaw 不为空时原样输出，优先于 Info
+		{InfoRaw: bencode.RawMessage("d4:name1:xe"), Info: genInfos[1]},
+	}
+	for _, tor := range torrents {
+		checkMarshal(t, tor, (*plainRawTorrent)(&tor))
+	}
+
+	resps := []TrackerResp{
+		{},
+		{Interval: 1800, MinInterval: -1, Peers: Peers{{IP: "127.0.0.1", Port: 6881}, {IP: "10.0.0.2", Port: 80}}},
+		{Peers: Peers{{IP: "::1", Port: 1}}}, // 紧凑格式不支持 IPv6，两边都应该出错
+	}
+	for _, resp := range resps {
+		checkMarshal(t, resp, (*plainTrackerResp)(&resp))
+	}
+	for _, n := range []Node{{}, {IP: "1.2.3.4", Port: 65535}} {
+		checkMarshal(t, n, (*plainNode)(&n))
+	}
+}
+
+// checkMarshal 比较生成的方法和反射的编码结果，再比较两者解码编码结果得到的值
+func checkMarshal[T, P any](t *testing.T, v T, plain *P) {
+	t.Helper()
+	got, gerr := bencode.Marshal(v)
+	want, werr := bencode.Marshal(plain)
+	if (gerr == nil) != (werr == nil) || !bytes.Equal(got, want) {
+		t.Fatalf("Marshal(%+v):\ngot  %q, %v\nwant %q, %v", v, got, gerr, want, werr)
+	}
+	if werr == nil {
+		checkUnmarshal[T, P](t, want)
+	}
+}
+
+// checkUnmarshal 比较生成的方法和反射的解码结果，P 必须是 T 对应的没有方法的类型
+func checkUnmarshal[T, P any](t *testing.T, data []byte) {
+	t.Helper()
+	var x T
+	var p P
+	gerr := bencode.Unmarshal(data, &x)
+	werr := bencode.Unmarshal(data, &p)
+	// 错误信息中的类型名去掉 plain 前缀之后应该完全相同
+	if (gerr == nil) != (werr == nil) || werr != nil && gerr.Error() != strings.ReplaceAll(werr.Error(), "plain", "") {
+		t.Fatalf("Unmarshal(%q):\ngot  %v\nwant %v", data, gerr, werr)
+	}
+	if werr != nil {
+		return
+	}
+	if !reflect.DeepEqual(reflect.ValueOf(&x).Convert(reflect.TypeOf(&p)).Interface(), &p) {
+		t.Fatalf("Unmarshal(%q):\ngot  %+v\nwant %+v", data, x, p)
+	}
+}
+
+func TestGeneratedUnmarshal(t *testing.T) {
+	infos := []string{
+		"d4:name1:a12:piece lengthi16384e6:pieces0:e",
+		"d5:filesld6:lengthi1e4:pathl1:aeee4:name1:a12:piece lengthi1e6:pieces0:7:privatei1e6:source3:abce",
+		"d3:fooli1ei2ee4:name1:a12:piece lengthi1e6:pieces0:e", // 未知的 key
+		"d4:name1:a6:pieces0:e",                   // 缺少 piece length
+		"d4:namei1e12:piece lengthi1e6:pieces0:e", // 类型错误
+		"d5:filesld6:lengthi1eee4:name1:a12:piece lengthi1e6:pieces0:e",
+		"d5:files1:a4:name1:a12:piece lengthi1e6:pieces0:e",
+		"d6:lengthi99999999999999999999e4:name1:a12:piece lengthi1e6:pieces0:e",
+		"le",
+	}
+	for _, in := range infos {
+		checkUnmarshal[RawInfo, plainRawInfo](t, []byte(in))
+		checkUnmarshal[RawTorrent, plainRawTorrent](t, []byte("d8:announce1:a4:info"+in+"e"))
+	}
+	checkUnmarshal[RawTorrent, plainRawTorrent](t, []byte("d8:announce1:ae"))
+	checkUnmarshal[RawTorrent, plainRawTorrent](t, []byte("d5:nodesll1:ai1eeee4:infod4:name1:a12:piece lengthi1e6:pieces0:ee"))
+
+	resps := []string{
+		"d8:intervali1800e5:peers12:\x7f\x00\x00\x01\x1a\xe1\x0a\x00\x00\x02\x00\x50e",
+		"d5:peersld2:ip9:127.0.0.14:porti6881eeee",
+		"d5:peers5:abcdee",
+		"d8:interval1:ae",
+	}
+	for _, in := range resps {
+		checkUnmarshal[TrackerResp, plainTrackerResp](t, []byte(in))
+	}
+}
+
+func TestGeneratedErrorOffset(t *testing.T) {
+	// 嵌套的生成方法共享同一个 Scanner，错误的路径和偏移量与反射解码相同
+	data := []byte("d4:infod5:filesld6:lengthi1e4:pathl1:aeed6:length1:xeeee")
+	var tor RawTorrent
+	err := bencode.Unmarshal(data, &tor)
+	var te *bencode.UnmarshalTypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v, want *UnmarshalTypeError", err)
+	}
+	if te.Field != "info.files[1].length" || te.Offset != 49 {
+		t.Fatalf("got field %q offset %d", te.Field, te.Offset)
+	}
+}
+
+func BenchmarkTrackerResp(b *testing.B) {
+	data := []byte("d8:intervali1800e12:min intervali900e5:peers12:\x7f\x00\x00\x01\x1a\xe1\x0a\x00\x00\x02\x00\x50e")
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp TrackerResp
+			bencode.Unmarshal(data, &resp)
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp plainTrackerResp
+			bencode.Unmarshal(data, &resp)
+		}
+	})
+}
+
+func BenchmarkRawInfoMarshal(b *testing.B) {
+	info := genInfos[2]
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bencode.Marshal(info)
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		p := plainRawInfo(info)
+		for i := 0; i < b.N; i++ {
+			bencode.Marshal(&p)
+		}
+	})
+}
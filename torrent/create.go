@@ -0,0 +1,291 @@
+package torrent
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+const (
+	minPieceLength = 16 << 10
+	maxPieceLength = 16 << 20
+	// 自动选择分片长度时的目标分片数
+	targetPieces = 1500
+)
+
+var (
+	ErrNoFiles = errors.New("no files to add")
+)
+
+// CreateOptions 创建种子的选项，零值表示使用默认值或者不写入对应的 key
+type CreateOptions struct {
+	// PieceLength 分片长度，必须是 2 的幂，为 0 时根据总长度自动选择
+	PieceLength int64
+	// AnnounceList 按层级排列的 tracker，第一层的第一个 tracker 同时作为 announce
+	AnnounceList [][]string
+	URLList      []string // BEP 19 web seed
+	Comment      string
+	CreatedBy    string
+	// CreationDate 创建时间，为零值时使用当前时间
+	CreationDate time.Time
+	Private      bool
+	Source       string
+	// Workers 并行计算分片哈希的 goroutine 数量，为 0 时使用 CPU 数量
+	Workers int
+}
+
+// createFile 需要读取的文件，offset 为在所有数据中的偏移量
+type createFile struct {
+	path   string
+	parts  []string
+	length int64
+	offset int64
+}
+
+// Create 从文件或目录创建种子，目录中的普通文件按照路径排序，符号链接等其他文件被忽略
+func Create(root string, opts CreateOptions) (*Torrent, error) {
+	if opts.PieceLength < 0 || opts.PieceLength&(opts.PieceLength-1) != 0 {
+		return nil, fmt.Errorf("%w: %d is not a power of 2", ErrPieceLength, opts.PieceLength)
+	}
+
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	files, total, err := walkFiles(root, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	pieceLength := opts.PieceLength
+	if pieceLength == 0 {
+		pieceLength = autoPieceLength(total)
+	}
+	pieces, err := hashPieces(files, total, pieceLength, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用绝对路径，避免 Create(".") 得到名称 "."
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	info := RawInfo{
+		Name:        filepath.Base(abs),
+		PieceLength: pieceLength,
+		Pieces:      string(pieces),
+	}
+	if fi.IsDir() {
+		info.Files = make([]RawFile, len(files))
+		for i, f := range files {
+			info.Files[i] = RawFile{Length: f.length, Path: f.parts}
+		}
+	} else {
+		info.Length = &total
+	}
+	if opts.Private {
+		private := int64(1)
+		info.Private = &private
+	}
+	if opts.Source != "" {
+		info.Source = &opts.Source
+	}
+
+	created := opts.CreationDate
+	if created.IsZero() {
+		created = time.Now()
+	}
+	raw := RawTorrent{
+		AnnounceList: opts.AnnounceList,
+		UrlList:      opts.URLList,
+		Comment:      opts.Comment,
+		CreateAt:     created.Unix(),
+		CreateBy:     opts.CreatedBy,
+		Info:         info,
+	}
+	if len(opts.AnnounceList) > 0 && len(opts.AnnounceList[0]) > 0 {
+		raw.Anonunce = opts.AnnounceList[0][0]
+	}
+
+	data, err := bencode.Marshal(&raw)
+	if err != nil {
+		return nil, err
+	}
+	// 数据由 Create 自己生成，不受默认资源限制，文件很多的目录也可以创建
+	return newTorrent("", data, bencode.DecodeOptions{Limits: &bencode.Limits{}})
+}
+
+// walkFiles 列出 root 中需要加入种子的文件和总长度
+func walkFiles(root string, fi fs.FileInfo) ([]createFile, int64, error) {
+	if !fi.IsDir() {
+		if !fi.Mode().IsRegular() {
+			return nil, 0, fmt.Errorf("%s: not a regular file", root)
+		}
+		return []createFile{{path: root, parts: []string{fi.Name()}, length: fi.Size()}}, fi.Size(), nil
+	}
+
+	var files []createFile
+	var total int64
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, createFile{
+			path:   p,
+			parts:  strings.Split(filepath.ToSlash(rel), "/"),
+			length: fi.Size(),
+			offset: total,
+		})
+		total += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(files) == 0 {
+		return nil, 0, fmt.Errorf("%w: %s", ErrNoFiles, root)
+	}
+	return files, total, nil
+}
+
+// autoPieceLength 选择使分片数接近 targetPieces 的 2 的幂
+func autoPieceLength(total int64) int64 {
+	n := int64(minPieceLength)
+	for n < maxPieceLength && total/n > targetPieces {
+		n <<= 1
+	}
+	return n
+}
+
+// hashPieces 使用 workers 个 goroutine 并行计算所有分片的 SHA-1
+func hashPieces(files []createFile, total, pieceLength int64, workers int) ([]byte, error) {
+	count := int((total + pieceLength - 1) / pieceLength)
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, count)
+
+	pieces := make([]byte, count*SHALEN)
+	jobs := make(chan int)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &pieceReader{files: files}
+			defer r.close()
+			buf := make([]byte, pieceLength)
+			for idx := range jobs {
+				off := int64(idx) * pieceLength
+				n := min(pieceLength, total-off)
+				err := r.readAt(buf[:n], off)
+				if err != nil {
+					errs <- err
+					// 继续取出剩余的任务，避免阻塞发送方
+					for range jobs {
+					}
+					return
+				}
+				sum := sha1.Sum(buf[:n])
+				copy(pieces[idx*SHALEN:], sum[:])
+			}
+		}()
+	}
+
+	var err error
+send:
+	for idx := 0; idx < count; idx++ {
+		select {
+		case jobs <- idx:
+		case err = <-errs:
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pieces, nil
+}
+
+// pieceReader 跨文件读取分片数据，保持最近使用的文件打开
+type pieceReader struct {
+	files []createFile
+	cur   int
+	f     *os.File
+}
+
+func (r *pieceReader) readAt(buf []byte, off int64) error {
+	// 第一个结束位置大于 off 的文件
+	i := sort.Search(len(r.files), func(i int) bool {
+		return r.files[i].offset+r.files[i].length > off
+	})
+	for len(buf) > 0 {
+		if i >= len(r.files) {
+			return io.ErrUnexpectedEOF
+		}
+		cf := r.files[i]
+		if cf.length == 0 {
+			i++
+			continue
+		}
+		if r.f == nil || r.cur != i {
+			r.close()
+			f, err := os.Open(cf.path)
+			if err != nil {
+				return err
+			}
+			r.f, r.cur = f, i
+		}
+		n := min(int64(len(buf)), cf.offset+cf.length-off)
+		_, err := r.f.ReadAt(buf[:n], off-cf.offset)
+		if err != nil {
+			// 读取期间文件被截断
+			if errors.Is(err, io.EOF) {
+				err = fmt.Errorf("%s: %w", cf.path, io.ErrUnexpectedEOF)
+			}
+			return err
+		}
+		buf, off = buf[n:], off+n
+		i++
+	}
+	return nil
+}
+
+func (r *pieceReader) close() {
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+}
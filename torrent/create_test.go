@@ -0,0 +1,190 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// writeFiles 在临时目录中创建 files 中的文件，返回目录路径
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(p), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCreateDir(t *testing.T) {
+	root := filepath.Join(writeFiles(t, map[string]string{
+		"data/b.txt":     "0123456789",
+		"data/a/x.bin":   "abc",
+		"data/a/y.bin":   "",
+		"data/c/d/e.txt": "hello, world",
+	}), "data")
+
+	opts := CreateOptions{
+		PieceLength:  8,
+		AnnounceList: [][]string{{"http://a/announce", "http://b/announce"}, {"udp://c:80"}},
+		URLList:      []string{"http://seed/"},
+		Comment:      "dataset",
+		CreatedBy:    "torrent",
+		CreationDate: time.Unix(1700000000, 0),
+		Private:      true,
+		Source:       "lab",
+		Workers:      3,
+	}
+	tor, err := Create(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 所有文件按照路径顺序拼接后分片
+	content := "abc" + "" + "0123456789" + "hello, world"
+	var pieces []byte
+	for off := 0; off < len(content); off += 8 {
+		sum := sha1.Sum([]byte(content[off:min(off+8, len(content))]))
+		pieces = append(pieces, sum[:]...)
+	}
+	info := tor.Raw().Info
+	if info.Pieces != string(pieces) {
+		t.Fatalf("pieces = %x, want %x", info.Pieces, pieces)
+	}
+	wantFiles := []RawFile{
+		{Length: 3, Path: []string{"a", "x.bin"}},
+		{Length: 0, Path: []string{"a", "y.bin"}},
+		{Length: 10, Path: []string{"b.txt"}},
+		{Length: 12, Path: []string{"c", "d", "e.txt"}},
+	}
+	if info.Name != "data" || info.Length != nil || !reflect.DeepEqual(info.Files, wantFiles) {
+		t.Fatalf("info = %+v", info)
+	}
+	if *info.Private != 1 || *info.Source != "lab" {
+		t.Fatalf("private %v, source %v", *info.Private, *info.Source)
+	}
+	if tor.Raw().Anonunce != "http://a/announce" || tor.Raw().CreateAt != 1700000000 || tor.Raw().Comment != "dataset" {
+		t.Fatalf("raw = %+v", tor.Raw())
+	}
+	if tor.Base.Size != int64(len(content)) || len(tor.Base.Files) != 4 || tor.Base.Files[3].Path != "data/c/d/e.txt" {
+		t.Fatalf("base = %+v", tor.Base)
+	}
+
+	// Save 输出规范的 bencode，重新加载后 info hash 不变
+	var buf bytes.Buffer
+	err = tor.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bencode.Valid(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "data.torrent")
+	err = os.WriteFile(file, buf.Bytes(), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewTorrent(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Base.Sha1 != tor.Base.Sha1 {
+		t.Fatalf("info hash changed: %x != %x", loaded.Base.Sha1, tor.Base.Sha1)
+	}
+	infoRaw, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tor.Base.Sha1 != sha1.Sum(infoRaw) {
+		t.Fatalf("info hash is not the hash of the info dict")
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.iso": "single"})
+	tor, err := Create(filepath.Join(dir, "a.iso"), CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := tor.Raw().Info
+	sum := sha1.Sum([]byte("single"))
+	if info.Name != "a.iso" || info.Files != nil || *info.Length != 6 || info.Pieces != string(sum[:]) {
+		t.Fatalf("info = %+v", info)
+	}
+	if info.PieceLength != minPieceLength || info.Private != nil || info.Source != nil {
+		t.Fatalf("info = %+v", info)
+	}
+	if tor.Raw().CreateAt == 0 {
+		t.Fatalf("creation date not set")
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a": "x"})
+	_, err := Create(dir, CreateOptions{PieceLength: 1000})
+	if !errors.Is(err, ErrPieceLength) {
+		t.Fatalf("got %v, want ErrPieceLength", err)
+	}
+	_, err = Create(t.TempDir(), CreateOptions{})
+	if !errors.Is(err, ErrNoFiles) {
+		t.Fatalf("got %v, want ErrNoFiles", err)
+	}
+	_, err = Create(filepath.Join(dir, "missing"), CreateOptions{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want ErrNotExist", err)
+	}
+}
+
+func TestAutoPieceLength(t *testing.T) {
+	tests := map[int64]int64{
+		0:        minPieceLength,
+		1 << 20:  minPieceLength,
+		1 << 30:  1 << 20,
+		1 << 40:  maxPieceLength,
+		42 << 30: maxPieceLength,
+	}
+	for total, want := range tests {
+		if got := autoPieceLength(total); got != want {
+			t.Errorf("autoPieceLength(%d) = %d, want %d", total, got, want)
+		}
+	}
+}
+
+func TestCreateRelativeRoot(t *testing.T) {
+	dir := filepath.Join(writeFiles(t, map[string]string{"dataset/a.txt": "abc"}), "dataset")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, root := range []string{".", "./", "../dataset"} {
+		tor, err := Create(root, CreateOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", root, err)
+		}
+		if tor.Raw().Info.Name != "dataset" || tor.Base.Files[0].Path != "dataset/a.txt" {
+			t.Fatalf("%s: name %q, files %+v", root, tor.Raw().Info.Name, tor.Base.Files)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package torrent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// 修改种子时只替换对应 key 的原始数据，其余的字节（包括未知的 key 和 info 字典）保持不变
+
+// Save 将种子的原始数据写入 w，包括通过 Set 系列方法修改的字段和所有未知的 key
+// 数据按字节原样输出：Create 创建的种子是规范的 bencode，加载的种子保持原来的编码，
+// 因为重新编码不规范的 info 字典会改变 info hash
+func (tor *Torrent) Save(w io.Writer) error {
+	_, err := w.Write(tor.data)
+	return err
+}
+
+// SetAnnounceList 设置按层级排列的 tracker，空的层级被忽略，announce 设置为第一个 tracker
+// tiers 中没有 tracker 时删除 announce 和 announce-list
+func (tor *Torrent) SetAnnounceList(tiers [][]string) error {
+	var list [][]string
+	for _, tier := range tiers {
+		if len(tier) > 0 {
+			list = append(list, tier)
+		}
+	}
+	if list == nil {
+		return tor.edit(deleteKey("announce"), deleteKey("announce-list"))
+	}
+	return tor.edit(setKey("announce", list[0][0]), setKey("announce-list", list))
+}
+
+// SetComment 设置注释，comment 为空时删除该 key
+func (tor *Torrent) SetComment(comment string) error {
+	if comment == "" {
+		return tor.edit(deleteKey("comment"))
+	}
+	return tor.edit(setKey("comment", comment))
+}
+
+// SetWebSeeds 设置 BEP 19 web seed，urls 为空时删除 url-list
+func (tor *Torrent) SetWebSeeds(urls []string) error {
+	if len(urls) == 0 {
+		return tor.edit(deleteKey("url-list"))
+	}
+	return tor.edit(setKey("url-list", urls))
+}
+
+// SetRaw 将 path 对应的值替换为 value，path 的格式与 bencode.SetRaw 相同
+// 修改 info 中的值会改变 info hash，可以先用 ChangesInfoHash 检查
+func (tor *Torrent) SetRaw(path string, value []byte) error {
+	return tor.edit(func(data []byte) ([]byte, error) {
+		return bencode.SetRaw(data, path, value)
+	})
+}
+
+// ChangesInfoHash 判断 edit 是否会改变种子的 info hash，edit 作用于种子的副本，tor 本身不会被修改
+func (tor *Torrent) ChangesInfoHash(edit func(*Torrent) error) (bool, error) {
+	// 重新解析原始数据得到独立的副本，edit 直接修改 Base 等字段也不会影响 tor
+	clone, err := newTorrent(tor.file, bytes.Clone(tor.data), tor.decode)
+	if err != nil {
+		return false, err
+	}
+	err = edit(clone)
+	if err != nil {
+		return false, err
+	}
+	return clone.Base.Sha1 != tor.Base.Sha1, nil
+}
+
+// editFunc 修改种子的原始数据，返回新的数据
+type editFunc func(data []byte) ([]byte, error)
+
+// setKey 将顶层的 key 设置为 v 的编码
+func setKey(key string, v any) editFunc {
+	return func(data []byte) ([]byte, error) {
+		value, err := bencode.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return bencode.SetRaw(data, key, value)
+	}
+}
+
+// deleteKey 删除顶层的 key，key 不存在时不做修改
+func deleteKey(key string) editFunc {
+	return func(data []byte) ([]byte, error) {
+		out, err := bencode.DeleteRaw(data, key)
+		if errors.Is(err, bencode.ErrKeyNotFound) {
+			return data, nil
+		}
+		return out, err
+	}
+}
+
+// edit 依次执行 edits 并重新解析种子，任何一步失败时 tor 保持不变
+// 已经获取的 peer 被保留，tracker 列表改变后从第一个 tracker 重新开始
+func (tor *Torrent) edit(edits ...editFunc) error {
+	var err error
+	data := tor.data
+	for _, e := range edits {
+		data, err = e(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	parsed, err := newTorrent(tor.file, data, tor.decode)
+	if err != nil {
+		return err
+	}
+	tor.data = parsed.data
+	tor.raw = parsed.raw
+	tor.Base = parsed.Base
+	tracker := *tor.Tracker
+	tracker.Trackers = parsed.Tracker.Trackers
+	tracker.HttpSeed = parsed.Tracker.HttpSeed
+	tor.Tracker = &tracker
+	tor.trackerIndex = -1
+	return nil
+}
@@ -0,0 +1,225 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// editInfo 包含 RawInfo 中没有的 key，重新编码 RawInfo 会改变 info hash
+const editInfo = "d6:lengthi5e4:name5:a.txt12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa8:x-customi7ee"
+
+func loadEditTorrent(t *testing.T) *Torrent {
+	t.Helper()
+	data := "d8:announce8:http://a7:comment3:old4:info" + editInfo + "8:url-listl8:http://ee7:x-extra5:valuee"
+	tor, err := LoadBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tor
+}
+
+// saved 返回 Save 写出的数据
+func saved(t *testing.T, tor *Torrent) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := tor.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEdit(t *testing.T) {
+	tor := loadEditTorrent(t)
+	hash := sha1.Sum([]byte(editInfo))
+	if tor.Base.Sha1 != hash {
+		t.Fatalf("info hash %x, want %x", tor.Base.Sha1, hash)
+	}
+
+	err := tor.SetAnnounceList([][]string{{}, {"udp://b:80", "udp://c:80"}, {"http://d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tor.SetComment("new comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tor.SetWebSeeds(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "d8:announce10:udp://b:8013:announce-listll10:udp://b:8010:udp://c:80el8:http://dee" +
+		"7:comment11:new comment4:info" + editInfo + "7:x-extra5:valuee"
+	if got := saved(t, tor); string(got) != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+	if tor.Base.Sha1 != hash || tor.Base.Comment != "new comment" {
+		t.Fatalf("base = %+v", tor.Base)
+	}
+	if strings.Join(tor.Tracker.Trackers, " ") != "udp://b:80 udp://b:80 udp://c:80 http://d" || tor.Raw().UrlList != nil {
+		t.Fatalf("trackers = %v, url-list = %v", tor.Tracker.Trackers, tor.Raw().UrlList)
+	}
+
+	// 删除所有 tracker 和注释
+	err = tor.SetAnnounceList(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tor.SetComment("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "d4:info" + editInfo + "7:x-extra5:valuee"
+	if got := saved(t, tor); string(got) != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+	if tor.Raw().Anonunce != "" || tor.Raw().AnnounceList != nil || tor.Base.Sha1 != hash {
+		t.Fatalf("raw = %+v", tor.Raw())
+	}
+}
+
+func TestEditInvalid(t *testing.T) {
+	tor := loadEditTorrent(t)
+	before := saved(t, tor)
+
+	// 修改后无法解析时种子保持不变
+	err := tor.SetRaw("info", []byte("i1e"))
+	if err == nil {
+		t.Fatal("SetRaw accepted an invalid info dict")
+	}
+	if got := saved(t, tor); !bytes.Equal(got, before) || tor.Raw().Comment != "old" {
+		t.Fatalf("torrent changed after failed edit: %q", got)
+	}
+}
+
+func TestChangesInfoHash(t *testing.T) {
+	tor := loadEditTorrent(t)
+	before := saved(t, tor)
+
+	tests := []struct {
+		name string
+		edit func(*Torrent) error
+		want bool
+	}{
+		{"comment", func(tor *Torrent) error { return tor.SetComment("x") }, false},
+		{"trackers", func(tor *Torrent) error { return tor.SetAnnounceList([][]string{{"http://x"}}) }, false},
+		{"web seeds", func(tor *Torrent) error { return tor.SetWebSeeds([]string{"http://y"}) }, false},
+		{"unknown key", func(tor *Torrent) error { return tor.SetRaw("x-extra", []byte("i1e")) }, false},
+		{"private", func(tor *Torrent) error { return tor.SetRaw("info.private", []byte("i1e")) }, true},
+		{"source", func(tor *Torrent) error { return tor.SetRaw("info.source", []byte("3:abc")) }, true},
+	}
+	for _, tt := range tests {
+		got, err := tor.ChangesInfoHash(tt.edit)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+	if got := saved(t, tor); !bytes.Equal(got, before) {
+		t.Fatalf("ChangesInfoHash modified the torrent: %q", got)
+	}
+
+	// edit 直接修改副本的字段时不影响原来的种子
+	got, err := tor.ChangesInfoHash(func(t *Torrent) error {
+		t.Raw().Info.Name = "zzz"
+		t.Base.Name = "zzz"
+		t.Tracker.Trackers[0] = "zzz"
+		return nil
+	})
+	if err != nil || got {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if tor.Raw().Info.Name != "a.txt" || tor.Base.Name != "a.txt" || tor.Tracker.Trackers[0] != "http://a" {
+		t.Fatalf("ChangesInfoHash modified the torrent: %+v", tor.Raw())
+	}
+
+	// 对比：通过 RawTorrent 重新编码会丢失 info 中未知的 key
+	data, err := bencode.Marshal(&RawTorrent{Info: tor.Raw().Info})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := bencode.GetRaw(data, "info")
+	if sha1.Sum(info) == tor.Base.Sha1 {
+		t.Fatalf("re-encoded info unexpectedly kept the info hash")
+	}
+}
+
+func TestRawCopy(t *testing.T) {
+	tor := loadEditTorrent(t)
+	before := saved(t, tor)
+
+	// Raw 返回副本，修改它不影响种子和 Save 的输出
+	raw := tor.Raw()
+	raw.Comment = "changed"
+	raw.UrlList[0] = "http://x"
+	raw.Info.Name = "zzz"
+	raw.InfoRaw[0] = 'x'
+	if got := tor.Raw(); got.Comment != "old" || got.UrlList[0] != "http://e" || got.Info.Name != "a.txt" || got.InfoRaw[0] != 'd' {
+		t.Fatalf("Raw returned a shared value: %+v", got)
+	}
+	if got := saved(t, tor); !bytes.Equal(got, before) {
+		t.Fatalf("got %q, want %q", got, before)
+	}
+
+	// Set 系列方法修改后 Raw 返回新的值
+	err := tor.SetComment("x")
+	if err != nil || tor.Raw().Comment != "x" || raw.Comment != "changed" {
+		t.Fatalf("SetComment: %v, %q", err, tor.Raw().Comment)
+	}
+
+	info := &RawInfo{
+		Files:   []RawFile{{Length: 1, Path: []string{"a"}}},
+		Length:  ptr[int64](1),
+		Private: ptr[int64](1),
+		Source:  ptr("s"),
+	}
+	c := info.clone()
+	c.Files[0].Path[0] = "b"
+	*c.Length, *c.Private, *c.Source = 2, 0, "x"
+	if info.Files[0].Path[0] != "a" || *info.Length != 1 || *info.Private != 1 || *info.Source != "s" {
+		t.Fatalf("clone shares data: %+v", info)
+	}
+}
+
+func TestSaveKeepsEncoding(t *testing.T) {
+	// 加载的种子按字节原样保存，即使 key 没有排序
+	data := "d4:info" + editInfo + "8:announce8:http://ae"
+	tor, err := LoadBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(t, tor); string(got) != data {
+		t.Fatalf("got %q, want %q", got, data)
+	}
+}
+
+func TestURLListString(t *testing.T) {
+	// BEP 19 允许 url-list 是单个字符串
+	tor, err := LoadBytes([]byte("d4:info" + editInfo + "8:url-list8:http://ee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tor.Raw().UrlList) != 1 || tor.Raw().UrlList[0] != "http://e" || tor.Tracker.Trackers[0] != "http://e" {
+		t.Fatalf("url-list = %v, trackers = %v", tor.Raw().UrlList, tor.Tracker.Trackers)
+	}
+	err = tor.SetWebSeeds(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := saved(t, tor), "d4:info"+editInfo+"e"; string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	tor, err = LoadBytes([]byte("d4:info" + editInfo + "8:url-list0:e"))
+	if err != nil || tor.Raw().UrlList == nil || len(tor.Raw().UrlList) != 0 {
+		t.Fatalf("empty url-list: %v, %#v", err, tor.Raw().UrlList)
+	}
+	_, err = LoadBytes([]byte("d4:info" + editInfo + "8:url-listi1ee"))
+	if err == nil {
+		t.Fatal("LoadBytes accepted an integer url-list")
+	}
+}
@@ -0,0 +1,126 @@
+package torrent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// MaxTorrentSize Load 和 LoadURL 默认读取的 .torrent 数据的最大长度
+const MaxTorrentSize = 32 << 20
+
+var (
+	ErrTooLarge    = errors.New("torrent data too large")
+	ErrContentType = errors.New("unexpected content type")
+)
+
+// Loader 加载种子时的选项，零值与 NewTorrent、Load 等包级别函数的行为相同
+type Loader struct {
+	// Decode 解析种子使用的选项，默认使用 bencode.DefaultLimits，
+	// 文件很多的种子（每个文件至少 4 个元素）可能超过 MaxElements，需要设置更大的 Limits
+	// 之后通过 Set 系列方法修改种子时使用相同的选项
+	Decode bencode.DecodeOptions
+	// MaxSize Load 和 LoadURL 读取的最大字节数，为 0 时使用 MaxTorrentSize
+	MaxSize int64
+}
+
+// Load 从 r 读取 .torrent 数据创建 Torrent，数据超过 MaxTorrentSize 时返回 ErrTooLarge
+func Load(r io.Reader) (*Torrent, error) {
+	return Loader{}.Load(r)
+}
+
+// LoadBytes 从 .torrent 数据创建 Torrent，b 被复制，调用后可以修改
+func LoadBytes(b []byte) (*Torrent, error) {
+	return Loader{}.LoadBytes(b)
+}
+
+// LoadURL 通过 HTTP GET 下载 .torrent 文件，client 为 nil 时使用 http.DefaultClient
+// 只接受 application/x-bittorrent、application/octet-stream 或者没有 Content-Type 的响应
+func LoadURL(ctx context.Context, url string, client *http.Client) (*Torrent, error) {
+	return Loader{}.LoadURL(ctx, url, client)
+}
+
+// NewTorrent 与包级别的 NewTorrent 相同，按照 l 的选项解析
+func (l Loader) NewTorrent(file string) (*Torrent, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tor, err := newTorrent(file, data, l.Decode)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return tor, nil
+}
+
+// Load 与包级别的 Load 相同，数据超过 l.MaxSize 时返回 ErrTooLarge
+func (l Loader) Load(r io.Reader) (*Torrent, error) {
+	return l.load(r, "")
+}
+
+// LoadBytes 与包级别的 LoadBytes 相同，不检查 MaxSize
+func (l Loader) LoadBytes(b []byte) (*Torrent, error) {
+	return newTorrent("", bytes.Clone(b), l.Decode)
+}
+
+// LoadURL 与包级别的 LoadURL 相同，响应超过 l.MaxSize 时返回 ErrTooLarge
+func (l Loader) LoadURL(ctx context.Context, url string, client *http.Client) (*Torrent, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/x-bittorrent")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Join(ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(ErrNetwork, fmt.Errorf("status code: %d", resp.StatusCode))
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || mt != "application/x-bittorrent" && mt != "application/octet-stream" {
+			return nil, fmt.Errorf("%w: %s", ErrContentType, ct)
+		}
+	}
+	if resp.ContentLength > l.maxSize() {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, resp.ContentLength)
+	}
+	return l.load(resp.Body, url)
+}
+
+func (l Loader) maxSize() int64 {
+	if l.MaxSize > 0 {
+		return l.MaxSize
+	}
+	return MaxTorrentSize
+}
+
+// load 最多读取 MaxSize 字节，source 记录数据的来源
+func (l Loader) load(r io.Reader, source string) (*Torrent, error) {
+	max := l.maxSize()
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrTooLarge
+	}
+	tor, err := newTorrent(source, data, l.Decode)
+	if err != nil && source != "" {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return tor, err
+}
@@ -0,0 +1,166 @@
+package torrent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+// testTorrentData 返回一个单文件种子的编码和对应的 Torrent
+func testTorrentData(t *testing.T) ([]byte, *Torrent) {
+	t.Helper()
+	dir := writeFiles(t, map[string]string{"a.txt": "hello"})
+	tor, err := Create(filepath.Join(dir, "a.txt"), CreateOptions{AnnounceList: [][]string{{"http://a/announce"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = tor.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), tor
+}
+
+func TestLoad(t *testing.T) {
+	data, want := testTorrentData(t)
+
+	tor, err := Load(bytes.NewReader(data))
+	if err != nil || tor.Base.Sha1 != want.Base.Sha1 {
+		t.Fatalf("Load: %v", err)
+	}
+
+	b := bytes.Clone(data)
+	tor, err = LoadBytes(b)
+	if err != nil || tor.Base.Sha1 != want.Base.Sha1 {
+		t.Fatalf("LoadBytes: %v", err)
+	}
+	// 修改传入的数据不影响已经创建的 Torrent
+	clear(b)
+	if !bytes.Equal(tor.data, data) {
+		t.Fatalf("LoadBytes kept a reference to its argument")
+	}
+
+	_, err = Load(io.MultiReader(bytes.NewReader(data), strings.NewReader(strings.Repeat("x", MaxTorrentSize))))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+	_, err = LoadBytes([]byte("d4:infoi1ee"))
+	if err == nil {
+		t.Fatalf("LoadBytes accepted invalid info")
+	}
+}
+
+func TestLoadURL(t *testing.T) {
+	data, want := testTorrentData(t)
+	mux := http.NewServeMux()
+	handle := func(path, ct string, body []byte) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if ct != "" {
+				w.Header().Set("Content-Type", ct)
+			}
+			w.Write(body)
+		})
+	}
+	handle("/ok", "application/x-bittorrent", data)
+	handle("/octet", "application/octet-stream", data)
+	handle("/html", "text/html; charset=utf-8", data)
+	handle("/large", "application/x-bittorrent", bytes.Repeat([]byte("x"), MaxTorrentSize+1))
+	handle("/bad", "application/x-bittorrent", []byte("le"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	for _, path := range []string{"/ok", "/octet"} {
+		tor, err := LoadURL(ctx, srv.URL+path, nil)
+		if err != nil || tor.Base.Sha1 != want.Base.Sha1 {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{"/html", ErrContentType},
+		{"/large", ErrTooLarge},
+		{"/missing", ErrNetwork},
+	}
+	for _, tt := range tests {
+		_, err := LoadURL(ctx, srv.URL+tt.path, srv.Client())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.path, err, tt.err)
+		}
+	}
+	_, err := LoadURL(ctx, srv.URL+"/bad", nil)
+	if err == nil || !strings.Contains(err.Error(), srv.URL+"/bad") {
+		t.Fatalf("got %v, want error with url", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = LoadURL(cancelled, srv.URL+"/ok", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	data, want := testTorrentData(t)
+
+	// 资源限制通过 Loader 传入，不需要修改 bencode.DefaultLimits
+	small := Loader{Decode: bencode.DecodeOptions{Limits: &bencode.Limits{MaxElements: 5}}}
+	_, err := small.LoadBytes(data)
+	var le *bencode.LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxElements" {
+		t.Fatalf("got %v, want MaxElements", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "a.torrent")
+	err = os.WriteFile(file, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = small.NewTorrent(file)
+	if !errors.As(err, &le) || !strings.Contains(err.Error(), file) {
+		t.Fatalf("got %v, want MaxElements with file name", err)
+	}
+
+	// 修改种子后使用相同的选项重新解析
+	// 找到刚好可以解析该种子的限制，再留出添加 comment 需要的两个元素
+	var tor *Torrent
+	for n := 1; tor == nil; n++ {
+		exact := Loader{Decode: bencode.DecodeOptions{Strict: true, Limits: &bencode.Limits{MaxElements: n}}}
+		tor, err = exact.LoadBytes(data)
+		if err == nil {
+			tor, err = Loader{Decode: bencode.DecodeOptions{Strict: true, Limits: &bencode.Limits{MaxElements: n + 2}}}.Load(bytes.NewReader(data))
+			if err != nil || tor.Base.Sha1 != want.Base.Sha1 {
+				t.Fatalf("Load: %v", err)
+			}
+		} else if !errors.As(err, &le) {
+			t.Fatal(err)
+		}
+	}
+	err = tor.SetWebSeeds([]string{"http://a", "http://b", "http://c"})
+	if !errors.As(err, &le) {
+		t.Fatalf("got %v, want *LimitError", err)
+	}
+	err = tor.SetComment("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Loader{MaxSize: int64(len(data)) - 1}.Load(bytes.NewReader(data))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+}
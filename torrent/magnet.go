@@ -0,0 +1,262 @@
+package torrent
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// btmh 中 SHA2-256 multihash 的前缀：哈希函数 0x12，长度 0x20
+	multihashSHA256 = "1220"
+	SHA256LEN       = 32
+)
+
+var (
+	ErrMagnetScheme   = errors.New("not a magnet uri")
+	ErrMagnetInfoHash = errors.New("magnet uri has no valid info hash")
+	ErrMagnetParam    = errors.New("invalid magnet parameter")
+)
+
+// Magnet BEP 9 磁力链接，InfoHash 和 InfoHashV2 至少有一个不为零值
+type Magnet struct {
+	InfoHash   [SHALEN]byte    // xt=urn:btih:，十六进制或 base32
+	InfoHashV2 [SHA256LEN]byte // xt=urn:btmh:，BEP 52 SHA2-256 multihash
+	Name       string          // dn
+	Length     int64           // xl，为 0 表示未知
+	Trackers   []string        // tr
+	WebSeeds   []string        // ws
+	Peers      []string        // x.pe，host:port
+	SelectOnly []FileRange     // so，BEP 53
+}
+
+// FileRange 文件下标的闭区间
+type FileRange struct {
+	First, Last int
+}
+
+// ParseMagnet 解析磁力链接，不认识的参数被忽略，tr.1 这样带数字后缀的参数与不带后缀的相同
+func ParseMagnet(uri string) (*Magnet, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMagnetScheme, err)
+	}
+	if !strings.EqualFold(u.Scheme, "magnet") {
+		return nil, ErrMagnetScheme
+	}
+
+	var m Magnet
+	var v1, v2 bool
+	// 按照参数出现的顺序解析，保持 tracker 的顺序
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(param, "=")
+		value, err = url.QueryUnescape(value)
+		if err == nil {
+			switch magnetKey(key) {
+			case "xt":
+				err = m.parseExactTopic(value, &v1, &v2)
+			case "dn":
+				m.Name = value
+			case "xl":
+				m.Length, err = strconv.ParseInt(value, 10, 64)
+				if err == nil && m.Length < 0 {
+					err = strconv.ErrRange
+				}
+			case "tr":
+				m.Trackers = append(m.Trackers, value)
+			case "ws":
+				m.WebSeeds = append(m.WebSeeds, value)
+			case "x.pe":
+				m.Peers = append(m.Peers, value)
+			case "so":
+				m.SelectOnly, err = parseSelectOnly(value)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrMagnetParam, param, err)
+		}
+	}
+	if !v1 && !v2 {
+		return nil, ErrMagnetInfoHash
+	}
+	return &m, nil
+}
+
+// magnetKey 去掉参数名的数字后缀，例如 tr.1 返回 tr
+func magnetKey(key string) string {
+	i := strings.LastIndexByte(key, '.')
+	if i < 0 {
+		return key
+	}
+	_, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return key
+	}
+	return key[:i]
+}
+
+// parseExactTopic 解析 xt 参数，同一种哈希出现多次时必须相同
+func (m *Magnet) parseExactTopic(value string, v1, v2 *bool) error {
+	switch {
+	case hasPrefixFold(value, "urn:btih:"):
+		hash, err := parseBTIH(value[len("urn:btih:"):])
+		if err != nil {
+			return err
+		}
+		if *v1 && hash != m.InfoHash {
+			return errors.New("conflicting btih")
+		}
+		m.InfoHash, *v1 = hash, true
+
+	case hasPrefixFold(value, "urn:btmh:"):
+		mh := value[len("urn:btmh:"):]
+		if len(mh) != len(multihashSHA256)+SHA256LEN*2 || !strings.EqualFold(mh[:len(multihashSHA256)], multihashSHA256) {
+			return errors.New("unsupported multihash")
+		}
+		var hash [SHA256LEN]byte
+		_, err := hex.Decode(hash[:], []byte(mh[len(multihashSHA256):]))
+		if err != nil {
+			return err
+		}
+		if *v2 && hash != m.InfoHashV2 {
+			return errors.New("conflicting btmh")
+		}
+		m.InfoHashV2, *v2 = hash, true
+	}
+	// 其他类型的 xt 与 BitTorrent 无关，直接忽略
+	return nil
+}
+
+// parseBTIH 解析 40 个字符的十六进制或者 32 个字符的 base32 info hash
+func parseBTIH(s string) ([SHALEN]byte, error) {
+	var hash [SHALEN]byte
+	var err error
+	switch len(s) {
+	case SHALEN * 2:
+		_, err = hex.Decode(hash[:], []byte(s))
+	case 32:
+		_, err = base32.StdEncoding.Decode(hash[:], []byte(strings.ToUpper(s)))
+	default:
+		err = fmt.Errorf("btih length %d", len(s))
+	}
+	return hash, err
+}
+
+// parseSelectOnly 解析 BEP 53 的 so 参数，例如 0,2,4-6
+func parseSelectOnly(s string) ([]FileRange, error) {
+	var ranges []FileRange
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(first)
+		if err != nil || a < 0 {
+			return nil, fmt.Errorf("bad index %q", part)
+		}
+		b := a
+		if isRange {
+			b, err = strconv.Atoi(last)
+			if err != nil || b < a {
+				return nil, fmt.Errorf("bad range %q", part)
+			}
+		}
+		ranges = append(ranges, FileRange{First: a, Last: b})
+	}
+	return ranges, nil
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// Selected 判断第 i 个文件是否需要下载，没有 so 参数时选择所有文件
+func (m *Magnet) Selected(i int) bool {
+	if m.SelectOnly == nil {
+		return true
+	}
+	for _, r := range m.SelectOnly {
+		if i >= r.First && i <= r.Last {
+			return true
+		}
+	}
+	return false
+}
+
+// String 生成磁力链接，参数按照 xt、dn、xl、tr、ws、x.pe、so 的顺序输出
+func (m *Magnet) String() string {
+	var b strings.Builder
+	b.WriteString("magnet:?")
+	sep := ""
+	add := func(key, value string) {
+		b.WriteString(sep)
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(value)
+		sep = "&"
+	}
+
+	if m.InfoHash != [SHALEN]byte{} {
+		add("xt", "urn:btih:"+hex.EncodeToString(m.InfoHash[:]))
+	}
+	if m.InfoHashV2 != [SHA256LEN]byte{} {
+		add("xt", "urn:btmh:"+multihashSHA256+hex.EncodeToString(m.InfoHashV2[:]))
+	}
+	if m.Name != "" {
+		add("dn", url.QueryEscape(m.Name))
+	}
+	if m.Length > 0 {
+		add("xl", strconv.FormatInt(m.Length, 10))
+	}
+	for _, tr := range m.Trackers {
+		add("tr", url.QueryEscape(tr))
+	}
+	for _, ws := range m.WebSeeds {
+		add("ws", url.QueryEscape(ws))
+	}
+	for _, pe := range m.Peers {
+		add("x.pe", url.QueryEscape(pe))
+	}
+	if len(m.SelectOnly) > 0 {
+		parts := make([]string, len(m.SelectOnly))
+		for i, r := range m.SelectOnly {
+			parts[i] = strconv.Itoa(r.First)
+			if r.Last != r.First {
+				parts[i] += "-" + strconv.Itoa(r.Last)
+			}
+		}
+		add("so", strings.Join(parts, ","))
+	}
+	return b.String()
+}
+
+// Magnet 生成种子的磁力链接，包含 info hash、名称、总长度、去重后的 tracker 和 web seed
+func (tor *Torrent) Magnet() string {
+	m := Magnet{
+		InfoHash: tor.Base.Sha1,
+		Name:     tor.Base.Name,
+		Length:   tor.Base.Size,
+	}
+	seen := map[string]bool{}
+	add := func(list *[]string, u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			*list = append(*list, u)
+		}
+	}
+	add(&m.Trackers, tor.raw.Anonunce)
+	for _, tier := range tor.raw.AnnounceList {
+		for _, tr := range tier {
+			add(&m.Trackers, tr)
+		}
+	}
+	seen = map[string]bool{}
+	for _, ws := range tor.raw.UrlList {
+		add(&m.WebSeeds, ws)
+	}
+	return m.String()
+}
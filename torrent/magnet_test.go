@@ -0,0 +1,112 @@
+package torrent
+
+import (
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMagnet(t *testing.T) {
+	const hexHash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	var hash [SHALEN]byte
+	hex.Decode(hash[:], []byte(hexHash))
+
+	uri := "magnet:?xt=urn:btih:" + hexHash +
+		"&dn=Big+Buck%20Bunny&xl=276445467" +
+		"&tr=udp%3A%2F%2Ftracker.example%3A1337&tr.1=http://b/announce" +
+		"&ws=https%3A%2F%2Fseed.example%2F&x.pe=10.0.0.1:6881&so=0,2,4-6&foo=bar"
+	m, err := ParseMagnet(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Magnet{
+		InfoHash:   hash,
+		Name:       "Big Buck Bunny",
+		Length:     276445467,
+		Trackers:   []string{"udp://tracker.example:1337", "http://b/announce"},
+		WebSeeds:   []string{"https://seed.example/"},
+		Peers:      []string{"10.0.0.1:6881"},
+		SelectOnly: []FileRange{{0, 0}, {2, 2}, {4, 6}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %+v\nwant %+v", m, want)
+	}
+	for i, sel := range []bool{true, false, true, false, true, true, true, false} {
+		if m.Selected(i) != sel {
+			t.Errorf("Selected(%d) = %v", i, !sel)
+		}
+	}
+
+	// base32 与十六进制得到相同的 info hash
+	m, err = ParseMagnet("magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK")
+	if err != nil || m.InfoHash != hash {
+		t.Fatalf("base32: %x, %v", m.InfoHash, err)
+	}
+
+	// 只有 v2 info hash 的混合种子
+	v2 := "1220" + hexHash + "000102030405060708090a0b"
+	m, err = ParseMagnet("MAGNET:?xt=urn:btmh:" + v2)
+	if err != nil || hex.EncodeToString(m.InfoHashV2[:]) != v2[4:] || m.InfoHash != [SHALEN]byte{} {
+		t.Fatalf("btmh: %+v, %v", m, err)
+	}
+
+	// String 生成的链接可以解析回相同的值
+	m = want
+	m.InfoHashV2[0] = 1
+	got, err := ParseMagnet(m.String())
+	if err != nil || !reflect.DeepEqual(got, m) {
+		t.Fatalf("round trip %q: %+v, %v", m.String(), got, err)
+	}
+}
+
+func TestParseMagnetErrors(t *testing.T) {
+	const hexHash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	tests := []struct {
+		uri string
+		err error
+	}{
+		{"http://example.com/?xt=urn:btih:" + hexHash, ErrMagnetScheme},
+		{"magnet:?dn=x", ErrMagnetInfoHash},
+		{"magnet:?xt=urn:sha1:" + hexHash, ErrMagnetInfoHash},
+		{"magnet:?xt=urn:btih:abc", ErrMagnetParam},
+		{"magnet:?xt=urn:btih:" + hexHash[:39] + "z", ErrMagnetParam},
+		{"magnet:?xt=urn:btmh:1114" + hexHash, ErrMagnetParam},
+		{"magnet:?xt=urn:btih:" + hexHash + "&xt=urn:btih:" + hexHash[1:] + "0", ErrMagnetParam},
+		{"magnet:?xt=urn:btih:" + hexHash + "&xl=-1", ErrMagnetParam},
+		{"magnet:?xt=urn:btih:" + hexHash + "&so=3-1", ErrMagnetParam},
+		{"magnet:?xt=urn:btih:" + hexHash + "&dn=%zz", ErrMagnetParam},
+	}
+	for _, tt := range tests {
+		_, err := ParseMagnet(tt.uri)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseMagnet(%q): got %v, want %v", tt.uri, err, tt.err)
+		}
+	}
+}
+
+func TestTorrentMagnet(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a b.txt": "hello"})
+	tor, err := Create(filepath.Join(dir, "a b.txt"), CreateOptions{
+		AnnounceList: [][]string{{"http://a/announce"}, {"http://b/announce", "http://a/announce"}},
+		URLList:      []string{"http://seed/a b.txt", "http://mirror/a b.txt", "http://seed/a b.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseMagnet(tor.Magnet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Magnet{
+		InfoHash: tor.Base.Sha1,
+		Name:     "a b.txt",
+		Length:   5,
+		Trackers: []string{"http://a/announce", "http://b/announce"},
+		WebSeeds: []string{"http://seed/a b.txt", "http://mirror/a b.txt"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %+v\nwant %+v", m, want)
+	}
+}
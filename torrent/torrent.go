@@ -7,13 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/alctny/torrent/bencode"
 	"github.com/go-resty/resty/v2"
 )
 
+//go:generate go run ../cmd/bencodegen -type RawTorrent,RawInfo,RawFile,Node,TrackerResp -output bencode_gen.go
+
 const SHALEN = 20
 
 var (
@@ -24,6 +28,12 @@ var (
 	ErrNoPeers         = errors.New("no peers ant trackers")
 	ErrNetwork         = errors.New("network error")
 	ErrTrackerInvalide = errors.New("tracker invalide")
+	ErrPieceLength     = errors.New("piece length must be positive")
+	ErrFileLength      = errors.New("file length is negative")
+	ErrOffsetRange     = errors.New("offset out of range")
+	ErrFileIndex       = errors.New("file index out of range")
+	ErrFilePath        = errors.New("invalid file path")
+	ErrNoLength        = errors.New("info has neither length nor files")
 )
 
 var (
@@ -41,8 +51,11 @@ type Torrent struct {
 	// 原始数据
 	file string `bencode:"-"`
 	data []byte `bencode:"-"`
+	// 解析时使用的选项，修改种子后重新解析时使用
+	decode bencode.DecodeOptions `bencode:"-"`
+	// 原始数据解析后的结果，通过 Raw 获取副本
+	raw *RawTorrent `bencode:"-"`
 	// 资源信息
-	Raw     *RawTorrent  `bencode:"-"`
 	Base    *FileInfo    `bencode:"-"`
 	Tracker *TrackerInfo `bencode:"-"`
 	Peer    *PeerInfo    `bencode:"-"`
@@ -62,64 +75,158 @@ type TrackerInfo struct {
 }
 
 type FileInfo struct {
-	Sha1    [SHALEN]byte   `bencode:"-"`
-	Name    string         `bencode:"-"`
-	Size    int64          `bencode:"-"`
-	Ed2k    string         `bencode:"-"`
-	Comment string         `bencode:"-"`
-	Pieces  [][SHALEN]byte `bencode:"-"`
-	FileSha [SHALEN]byte   `bencode:"-"`
+	Sha1        [SHALEN]byte   `bencode:"-"`
+	Name        string         `bencode:"-"`
+	Size        int64          `bencode:"-"`
+	Ed2k        string         `bencode:"-"`
+	Comment     string         `bencode:"-"`
+	PieceLength int64          `bencode:"-"`
+	Pieces      [][SHALEN]byte `bencode:"-"`
+	FileSha     [SHALEN]byte   `bencode:"-"`
+	// Files 按照在种子中的顺序排列，单文件模式下只有一个文件
+	Files []File `bencode:"-"`
+}
+
+// File 种子中的一个文件及其在所有数据中的位置
+type File struct {
+	Path   string `bencode:"-"` // 以 / 分隔的路径，多文件模式下以种子名称作为第一级目录
+	Length int64  `bencode:"-"`
+	Offset int64  `bencode:"-"` // 文件第一个字节在种子所有数据中的偏移量
+	// 文件所在的第一个和最后一个分片，长度为 0 的文件 LastPiece 为 FirstPiece-1
+	FirstPiece int `bencode:"-"`
+	LastPiece  int `bencode:"-"`
 }
 
 type RawTorrent struct {
-	Anonunce     string     `bencode:"announce"`
-	AnnounceList [][]string `bencode:"announce-list"`
-	UrlList      []string   `bencode:"url-list"`
-	Node         [][2]any   `bencode:"nodes"`
-	Comment      string     `bencode:"comment"`
-	CreateAt     int64      `bencode:"creation date"`
-	CreateBy     string     `bencode:"created by"`
-	HttpSeed     []string   `bencode:"httpseeds"`
-	Encoding     string     `bencode:"encoding"`
-	Info         RawInfo    `bencode:"info"`
+	Anonunce     string     `bencode:"announce,omitempty"`
+	AnnounceList [][]string `bencode:"announce-list,omitempty"`
+	UrlList      URLList    `bencode:"url-list,omitempty"`
+	Node         [][2]any   `bencode:"nodes,omitempty"`
+	Comment      string     `bencode:"comment,omitempty"`
+	CreateAt     int64      `bencode:"creation date,omitempty"`
+	CreateBy     string     `bencode:"created by,omitempty"`
+	HttpSeed     []string   `bencode:"httpseeds,omitempty"`
+	Encoding     string     `bencode:"encoding,omitempty"`
+	// InfoRaw 保存 info 字典的原始数据，用于计算 info hash
+	// 两个字段使用同一个 key，InfoRaw 不为空时序列化原样输出 InfoRaw，
+	// 与 Info 的编码结果不同（修改了 Info 或者 info 中有 Info 没有的 key）时返回 bencode.ErrKeyConflict，
+	// 需要根据 Info 重新编码时调用 ReencodeInfo
+	InfoRaw bencode.RawMessage `bencode:"info"`
+	Info    RawInfo            `bencode:"info,required"`
 }
 
+// RawInfo info 字典，可选的 key 使用指针表示，nil 表示 key 不存在
+// Length 原来是拼写错误的 Lnegth int64，无法区分 length 为 0 和不存在，改为 *int64 后不再保留旧的字段
 type RawInfo struct {
-	Files       []RawFile `bencode:"files"`
-	Lnegth      int64     `bencode:"length"`
-	Name        string    `bencode:"name"`
-	PieceLength int64     `bencode:"piece length"`
-	Pieces      string    `bencode:"pieces"`
-	Pieces6     string    `bencode:"pieces6"`
-	NameUTF8    string    `bencode:"name.utf-8"`
-	Ed2K        string    `bencode:"ed2k"`
-	FileHash    []byte    `bencode:"filehash"`
+	Files       []RawFile `bencode:"files,omitempty"`
+	Length      *int64    `bencode:"length"` // 单文件模式下的文件长度
+	Name        string    `bencode:"name,required"`
+	PieceLength int64     `bencode:"piece length,required"`
+	Pieces      string    `bencode:"pieces,required"`
+	Pieces6     string    `bencode:"pieces6,omitempty"`
+	NameUTF8    string    `bencode:"name.utf-8,omitempty"`
+	Ed2K        string    `bencode:"ed2k,omitempty"`
+	FileHash    []byte    `bencode:"filehash,omitempty"`
+	Private     *int64    `bencode:"private"` // BEP 27，1 表示私有种子
+	Source      *string   `bencode:"source"`  // 用于区分不同站点发布的相同内容
+}
+
+// Raw 返回原始数据解析后的副本，修改返回值不会影响种子，修改种子需要使用 SetComment 等方法
+func (tor *Torrent) Raw() *RawTorrent {
+	return tor.raw.clone()
+}
+
+// clone 返回 raw 的深拷贝
+func (raw *RawTorrent) clone() *RawTorrent {
+	c := *raw
+	if raw.AnnounceList != nil {
+		c.AnnounceList = make([][]string, len(raw.AnnounceList))
+		for i, tier := range raw.AnnounceList {
+			c.AnnounceList[i] = slices.Clone(tier)
+		}
+	}
+	c.UrlList = slices.Clone(raw.UrlList)
+	c.Node = slices.Clone(raw.Node)
+	c.HttpSeed = slices.Clone(raw.HttpSeed)
+	c.InfoRaw = slices.Clone(raw.InfoRaw)
+	c.Info = *raw.Info.clone()
+	return &c
+}
+
+// clone 返回 info 的深拷贝
+func (info *RawInfo) clone() *RawInfo {
+	c := *info
+	if info.Files != nil {
+		c.Files = make([]RawFile, len(info.Files))
+		for i, f := range info.Files {
+			c.Files[i] = RawFile{Length: f.Length, Path: slices.Clone(f.Path)}
+		}
+	}
+	c.Length = clonePtr(info.Length)
+	c.FileHash = slices.Clone(info.FileHash)
+	c.Private = clonePtr(info.Private)
+	c.Source = clonePtr(info.Source)
+	return &c
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// ReencodeInfo 清空 InfoRaw，之后序列化时根据 Info 重新编码 info 字典
+// Info 中没有的 key 会丢失，info hash 通常会改变
+func (raw *RawTorrent) ReencodeInfo() {
+	raw.InfoRaw = nil
+}
+
+// URLList BEP 19 的 url-list，兼容单个字符串和字符串列表两种格式，编码时总是使用列表
+type URLList []string
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler，单个字符串解析为只有一个元素的列表，空字符串解析为空列表
+func (u *URLList) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenString {
+		return bencode.DecodeValue(s, tok, (*[]string)(u))
+	}
+	var url string
+	err := bencode.DecodeValue(s, tok, &url)
+	if err != nil {
+		return err
+	}
+	*u = URLList{}
+	if url != "" {
+		*u = URLList{url}
+	}
+	return nil
+}
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (u *URLList) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, u.UnmarshalBencodeFrom)
 }
 
 type RawFile struct {
-	Length int64    `bencode:"length"`
-	Path   []string `bencode:"path"`
+	Length int64    `bencode:"length,required"`
+	Path   []string `bencode:"path,required"`
 }
 
 type Node struct {
-	IP   string `bencode:"-"`
-	Port int64  `bencode:"-"`
+	IP   string `bencode:"ip"`
+	Port int64  `bencode:"port"`
 }
 
 // NewTorrent 从 .torrent 文件创建 Torrent 结构
 func NewTorrent(file string) (*Torrent, error) {
-	data, err := os.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
-	var raw RawTorrent
-	err = bencode.Unmarshal(data, &raw)
-	if err != nil {
-		return nil, err
-	}
+	return Loader{}.NewTorrent(file)
+}
 
-	// 资源唯一标识
-	infoRaw, err := bencode.GetRaw(data, "info")
+// newTorrent 按照 opts 解析 .torrent 文件的内容，file 只用于记录来源
+func newTorrent(file string, data []byte, opts bencode.DecodeOptions) (*Torrent, error) {
+	var raw RawTorrent
+	err := opts.Unmarshal(data, &raw)
 	if err != nil {
 		return nil, err
 	}
@@ -136,12 +243,10 @@ func NewTorrent(file string) (*Torrent, error) {
 		return nil, err
 	}
 
-	// lengthSum
-	lengthSum := raw.Info.Lnegth
-	if lengthSum != 0 {
-		for _, rif := range raw.Info.Files {
-			lengthSum += rif.Length
-		}
+	// 文件列表和总长度
+	files, lengthSum, err := FileLayout(&raw.Info)
+	if err != nil {
+		return nil, err
 	}
 
 	// tracker list
@@ -165,17 +270,20 @@ func NewTorrent(file string) (*Torrent, error) {
 	}
 
 	tor := &Torrent{
-		file: file,
-		data: data,
-		Raw:  &raw,
+		file:   file,
+		data:   data,
+		decode: opts,
+		raw:    &raw,
 		Base: &FileInfo{
-			Sha1:    sha1.Sum(infoRaw),
-			Name:    raw.Info.Name,
-			Size:    lengthSum,
-			Ed2k:    raw.Info.Ed2K,
-			Comment: raw.Comment,
-			Pieces:  pieces,
-			FileSha: fileSha,
+			Sha1:        sha1.Sum(raw.InfoRaw),
+			Name:        raw.Info.Name,
+			Size:        lengthSum,
+			Ed2k:        raw.Info.Ed2K,
+			Comment:     raw.Comment,
+			PieceLength: raw.Info.PieceLength,
+			Pieces:      pieces,
+			FileSha:     fileSha,
+			Files:       files,
 		},
 		Tracker: &TrackerInfo{
 			Trackers: tracker,
@@ -195,6 +303,95 @@ func NewTorrent(file string) (*Torrent, error) {
 	return tor, nil
 }
 
+// FileLayout 计算 info 中每个文件的路径、偏移量和所在的分片，返回文件列表和总长度
+func FileLayout(info *RawInfo) ([]File, int64, error) {
+	if info.PieceLength <= 0 {
+		return nil, 0, ErrPieceLength
+	}
+
+	var files []File
+	var offset int64
+	add := func(p string, length int64) error {
+		if length < 0 {
+			return fmt.Errorf("%w: %s", ErrFileLength, p)
+		}
+		f := File{Path: p, Length: length, Offset: offset}
+		f.FirstPiece = int(offset / info.PieceLength)
+		f.LastPiece = f.FirstPiece - 1
+		if length > 0 {
+			f.LastPiece = int((offset + length - 1) / info.PieceLength)
+		}
+		files = append(files, f)
+		offset += length
+		return nil
+	}
+
+	err := checkSegment(info.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: name %q", ErrFilePath, info.Name)
+	}
+
+	// 单文件模式
+	if info.Files == nil {
+		if info.Length == nil {
+			return nil, 0, ErrNoLength
+		}
+		err := add(info.Name, *info.Length)
+		if err != nil {
+			return nil, 0, err
+		}
+		return files, offset, nil
+	}
+
+	files = make([]File, 0, len(info.Files))
+	for i, rf := range info.Files {
+		if len(rf.Path) == 0 {
+			return nil, 0, fmt.Errorf("%w: files[%d] has empty path", ErrFilePath, i)
+		}
+		for _, seg := range rf.Path {
+			err := checkSegment(seg)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: files[%d] %q", ErrFilePath, i, rf.Path)
+			}
+		}
+		err := add(info.Name+"/"+strings.Join(rf.Path, "/"), rf.Length)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return files, offset, nil
+}
+
+// checkSegment 检查路径中的一级，拒绝空字符串、. 、.. 和包含分隔符的名称，避免文件被写到种子目录之外
+func checkSegment(seg string) error {
+	if seg == "" || seg == "." || seg == ".." || strings.ContainsAny(seg, `/\`) {
+		return ErrFilePath
+	}
+	return nil
+}
+
+// FileForOffset 返回包含偏移量 off 的文件的下标，长度为 0 的文件不包含任何偏移量
+func (tor *Torrent) FileForOffset(off int64) (int, error) {
+	if off < 0 || off >= tor.Base.Size {
+		return -1, ErrOffsetRange
+	}
+	files := tor.Base.Files
+	// 第一个结束位置大于 off 的文件，跳过了其前面长度为 0 的文件
+	i := sort.Search(len(files), func(i int) bool {
+		return files[i].Offset+files[i].Length > off
+	})
+	return i, nil
+}
+
+// PiecesForFile 返回第 i 个文件所在的第一个和最后一个分片，长度为 0 的文件 last 小于 first
+func (tor *Torrent) PiecesForFile(i int) (first, last int, err error) {
+	if i < 0 || i >= len(tor.Base.Files) {
+		return 0, -1, ErrFileIndex
+	}
+	f := tor.Base.Files[i]
+	return f.FirstPiece, f.LastPiece, nil
+}
+
 // TODO: 重试 并发 超时
 // TryGetPeer 从 tracker 获取 peers
 func (tor *Torrent) TryGetPeer() error {
@@ -237,13 +434,8 @@ func (tor *Torrent) TryGetPeer() error {
 	if err != nil {
 		return err
 	}
-	nodes, err := res.ParserPeers()
-	if err != nil {
-		return err
-	}
-
 	// TODO: 去重
-	tor.Peer.Peers = append(tor.Peer.Peers, nodes...)
+	tor.Peer.Peers = append(tor.Peer.Peers, res.Peers...)
 
 	return nil
 }
@@ -0,0 +1,213 @@
+package torrent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+func TestRawInfoOptional(t *testing.T) {
+	var info RawInfo
+	err := bencode.Unmarshal([]byte("d4:name1:a12:piece lengthi16384e6:pieces0:7:privatei0ee"), &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Length != nil || info.Source != nil {
+		t.Fatalf("absent keys decoded: length %v, source %v", info.Length, info.Source)
+	}
+	if info.Private == nil || *info.Private != 0 {
+		t.Fatalf("private = %v, want pointer to 0", info.Private)
+	}
+
+	// private 为 0 时依然保留，避免改变 info hash
+	data, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d4:name1:a12:piece lengthi16384e6:pieces0:7:privatei0ee"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
+
+// writeTorrent 将 raw 编码写入临时文件，返回文件路径
+func writeTorrent(t *testing.T, raw *RawTorrent) string {
+	t.Helper()
+	data, err := bencode.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "a.torrent")
+	err = os.WriteFile(file, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestFileLayout(t *testing.T) {
+	raw := &RawTorrent{
+		Anonunce: "http://tracker/announce",
+		Info: RawInfo{
+			Name:        "dir",
+			PieceLength: 4,
+			Pieces:      strings.Repeat("x", SHALEN*4),
+			Files: []RawFile{
+				{Length: 6, Path: []string{"a", "b.txt"}},
+				{Length: 0, Path: []string{"empty"}},
+				{Length: 2, Path: []string{"c"}},
+				{Length: 5, Path: []string{"d"}},
+			},
+		},
+	}
+	tor, err := NewTorrent(writeTorrent(t, raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tor.Base.Size != 13 {
+		t.Fatalf("size = %d, want 13", tor.Base.Size)
+	}
+	want := []File{
+		{Path: "dir/a/b.txt", Length: 6, Offset: 0, FirstPiece: 0, LastPiece: 1},
+		{Path: "dir/empty", Length: 0, Offset: 6, FirstPiece: 1, LastPiece: 0},
+		{Path: "dir/c", Length: 2, Offset: 6, FirstPiece: 1, LastPiece: 1},
+		{Path: "dir/d", Length: 5, Offset: 8, FirstPiece: 2, LastPiece: 3},
+	}
+	if !reflect.DeepEqual(tor.Base.Files, want) {
+		t.Fatalf("got %+v\nwant %+v", tor.Base.Files, want)
+	}
+
+	offsets := map[int64]int{0: 0, 5: 0, 6: 2, 7: 2, 8: 3, 12: 3}
+	for off, i := range offsets {
+		got, err := tor.FileForOffset(off)
+		if err != nil || got != i {
+			t.Errorf("FileForOffset(%d) = %d, %v, want %d", off, got, err, i)
+		}
+	}
+	for _, off := range []int64{-1, 13} {
+		_, err := tor.FileForOffset(off)
+		if !errors.Is(err, ErrOffsetRange) {
+			t.Errorf("FileForOffset(%d): got %v, want ErrOffsetRange", off, err)
+		}
+	}
+
+	first, last, err := tor.PiecesForFile(3)
+	if err != nil || first != 2 || last != 3 {
+		t.Fatalf("PiecesForFile(3) = %d, %d, %v", first, last, err)
+	}
+	_, _, err = tor.PiecesForFile(4)
+	if !errors.Is(err, ErrFileIndex) {
+		t.Fatalf("got %v, want ErrFileIndex", err)
+	}
+}
+
+func TestFileLayoutSingle(t *testing.T) {
+	info := &RawInfo{Name: "a.iso", PieceLength: 16384, Length: ptr[int64](16385)}
+	files, size, err := FileLayout(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []File{{Path: "a.iso", Length: 16385, FirstPiece: 0, LastPiece: 1}}
+	if size != 16385 || !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %+v, %d", files, size)
+	}
+
+	info.PieceLength = 0
+	_, _, err = FileLayout(info)
+	if !errors.Is(err, ErrPieceLength) {
+		t.Fatalf("got %v, want ErrPieceLength", err)
+	}
+	info = &RawInfo{Name: "d", PieceLength: 1, Files: []RawFile{{Length: -1, Path: []string{"x"}}}}
+	_, _, err = FileLayout(info)
+	if !errors.Is(err, ErrFileLength) {
+		t.Fatalf("got %v, want ErrFileLength", err)
+	}
+
+	// 既没有 length 也没有 files 时不是 0 字节的文件
+	info = &RawInfo{Name: "a", PieceLength: 1}
+	_, _, err = FileLayout(info)
+	if !errors.Is(err, ErrNoLength) {
+		t.Fatalf("got %v, want ErrNoLength", err)
+	}
+	_, err = LoadBytes([]byte("d4:infod4:name1:a12:piece lengthi1e6:pieces0:ee"))
+	if !errors.Is(err, ErrNoLength) {
+		t.Fatalf("LoadBytes: got %v, want ErrNoLength", err)
+	}
+	info.Length = ptr[int64](0)
+	files, size, err = FileLayout(info)
+	if err != nil || size != 0 || len(files) != 1 || files[0].LastPiece != -1 {
+		t.Fatalf("got %+v, %d, %v", files, size, err)
+	}
+}
+
+func TestFileLayoutPath(t *testing.T) {
+	// 这些路径会让文件出现在种子目录之外或者与其他文件冲突
+	paths := [][]string{
+		{"..", "..", "etc", "passwd"},
+		{"a", "..", "..", "b"},
+		{"/abs"},
+		{"a/b"},
+		{`a\b`},
+		{"."},
+		{"a", ""},
+		{},
+		nil,
+	}
+	for _, p := range paths {
+		info := &RawInfo{Name: "dir", PieceLength: 1, Files: []RawFile{{Length: 1, Path: []string{"ok"}}, {Length: 1, Path: p}}}
+		_, _, err := FileLayout(info)
+		if !errors.Is(err, ErrFilePath) {
+			t.Errorf("path %q: got %v, want ErrFilePath", p, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../x", "/abs"} {
+		info := &RawInfo{Name: name, PieceLength: 1, Length: ptr[int64](1)}
+		_, _, err := FileLayout(info)
+		if !errors.Is(err, ErrFilePath) {
+			t.Errorf("name %q: got %v, want ErrFilePath", name, err)
+		}
+	}
+
+	// 包含 .. 但不是完整一级的名称是合法的
+	info := &RawInfo{Name: "dir", PieceLength: 1, Files: []RawFile{{Length: 1, Path: []string{"a..b", ".hidden"}}}}
+	files, _, err := FileLayout(info)
+	if err != nil || files[0].Path != "dir/a..b/.hidden" {
+		t.Fatalf("got %+v, %v", files, err)
+	}
+}
+
+func TestReencodeInfo(t *testing.T) {
+	var raw RawTorrent
+	err := bencode.Unmarshal([]byte("d4:infod4:name1:a12:piece lengthi1e6:pieces0:ee"), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// InfoRaw 与 Info 一致时原样输出
+	data, err := bencode.Marshal(&raw)
+	if err != nil || !strings.Contains(string(data), "4:name1:a") {
+		t.Fatalf("got %q, %v, want original info", data, err)
+	}
+
+	// 修改 Info 但没有清空 InfoRaw 时返回错误，而不是静默丢弃修改
+	raw.Info.Name = "b"
+	_, err = bencode.Marshal(&raw)
+	if !errors.Is(err, bencode.ErrKeyConflict) {
+		t.Fatalf("got %v, want ErrKeyConflict", err)
+	}
+
+	raw.ReencodeInfo()
+	data, err = bencode.Marshal(&raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d4:infod4:name1:b12:piece lengthi1e6:pieces0:ee"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
@@ -2,26 +2,75 @@ package torrent
 
 import (
 	"fmt"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/alctny/torrent/bencode"
 )
 
 // TrackerResp  与 tracker 通信的响应，包含 Perrs 的信息
 type TrackerResp struct {
-	Interval    int64  `bencode:"interval"`
-	MinInterval int64  `bencode:"min interval"`
-	Peers       []byte `bencode:"peers"`
+	Interval    int64 `bencode:"interval"`
+	MinInterval int64 `bencode:"min interval"`
+	Peers       Peers `bencode:"peers"`
 }
 
-func (t *TrackerResp) ParserPeers() ([]Node, error) {
-	if len(t.Peers)%6 != 0 {
-		return nil, fmt.Errorf("invalid peer length, should be multiple of 6, but got %d", len(t.Peers))
+// Peers tracker 返回的 peer 列表，兼容紧凑格式（每个 peer 6 字节）和字典列表格式
+type Peers []Node
+
+// UnmarshalBencode 实现 bencode.Unmarshaler
+func (p *Peers) UnmarshalBencode(data []byte) error {
+	return bencode.UnmarshalFunc(data, p.UnmarshalBencodeFrom)
+}
+
+// UnmarshalBencodeFrom 实现 bencode.ScannerUnmarshaler，字典列表使用外层的严格模式和资源限制解码
+func (p *Peers) UnmarshalBencodeFrom(s *bencode.Scanner, tok bencode.Token) error {
+	if tok.Kind != bencode.TokenString {
+		return bencode.DecodeValue(s, tok, (*[]Node)(p))
+	}
+
+	var compact []byte
+	err := bencode.DecodeValue(s, tok, &compact)
+	if err != nil {
+		return err
 	}
-	len := len(t.Peers) / 6
+	if len(compact)%6 != 0 {
+		return fmt.Errorf("invalid peer length, should be multiple of 6, but got %d", len(compact))
+	}
+	len := len(compact) / 6
 	nodes := make([]Node, len)
 	for i := 0; i < len; i++ {
-		peer := t.Peers[i*6 : (i+1)*6]
+		peer := compact[i*6 : (i+1)*6]
 		ip := fmt.Sprintf("%d.%d.%d.%d", peer[0], peer[1], peer[2], peer[3])
 		port := int64(peer[4])<<8 + int64(peer[5])
 		nodes[i] = Node{IP: ip, Port: port}
 	}
-	return nodes, nil
+	*p = nodes
+	return nil
+}
+
+// MarshalBencode 实现 bencode.Marshaler，使用紧凑格式编码
+func (p Peers) MarshalBencode() ([]byte, error) {
+	compact := make([]byte, 0, len(p)*6)
+	for i, node := range p {
+		peer := net.JoinHostPort(node.IP, strconv.FormatInt(node.Port, 10))
+		ip := net.ParseIP(node.IP).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("peer %d (%s): compact format only supports ipv4 addresses", i, peer)
+		}
+		if node.Port < 0 || node.Port > math.MaxUint16 {
+			return nil, fmt.Errorf("peer %d (%s): port out of range", i, peer)
+		}
+		compact = append(compact, ip...)
+		compact = append(compact, byte(node.Port>>8), byte(node.Port))
+	}
+	return bencode.Marshal(string(compact))
+}
+
+// ParserPeers 返回 tracker 响应中的 peer 列表
+//
+// Deprecated: Peers 在解析响应时已经转换为 []Node，直接使用 t.Peers
+func (t *TrackerResp) ParserPeers() ([]Node, error) {
+	return t.Peers, nil
 }
@@ -0,0 +1,95 @@
+package torrent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alctny/torrent/bencode"
+)
+
+func TestTrackerRespPeers(t *testing.T) {
+	data := []byte("d8:intervali1800e5:peers12:\x7f\x00\x00\x01\x1a\xe1\x0a\x00\x00\x02\x00\x50e")
+	var resp TrackerResp
+	err := bencode.Unmarshal(data, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{{IP: "127.0.0.1", Port: 6881}, {IP: "10.0.0.2", Port: 80}}
+	if len(resp.Peers) != len(want) {
+		t.Fatalf("got %v, want %v", resp.Peers, want)
+	}
+	for i := range want {
+		if resp.Peers[i] != want[i] {
+			t.Fatalf("got %v, want %v", resp.Peers, want)
+		}
+	}
+	// 兼容旧的调用方式
+	nodes, err := resp.ParserPeers()
+	if err != nil || len(nodes) != len(want) || nodes[1] != want[1] {
+		t.Fatalf("ParserPeers: %v, %v", nodes, err)
+	}
+
+	var dict TrackerResp
+	err = bencode.Unmarshal([]byte("d5:peersld2:ip9:127.0.0.14:porti6881eeee"), &dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dict.Peers) != 1 || dict.Peers[0] != want[0] {
+		t.Fatalf("got %v", dict.Peers)
+	}
+}
+
+func TestPeersMarshalInvalid(t *testing.T) {
+	tests := []struct {
+		peers Peers
+		want  string
+	}{
+		{Peers{{IP: "127.0.0.1", Port: 65536}}, "peer 0 (127.0.0.1:65536): port out of range"},
+		{Peers{{IP: "127.0.0.1", Port: 80}, {IP: "10.0.0.1", Port: -1}}, "peer 1 (10.0.0.1:-1): port out of range"},
+		{Peers{{IP: "::1", Port: 80}}, "peer 0 ([::1]:80): compact format only supports ipv4 addresses"},
+		{Peers{{IP: "example.com", Port: 80}}, "peer 0 (example.com:80): compact format only supports ipv4 addresses"},
+	}
+	for _, tt := range tests {
+		_, err := bencode.Marshal(TrackerResp{Peers: tt.peers})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got %v, want %q", tt.peers, err, tt.want)
+		}
+	}
+
+	data, err := bencode.Marshal(Peers{{IP: "10.0.0.2", Port: 65535}})
+	if err != nil || string(data) != "6:\x0a\x00\x00\x02\xff\xff" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+}
+
+func TestPeersDecodeOptions(t *testing.T) {
+	// 字典列表格式的 peers 使用外层的严格模式和资源限制解码
+	data := []byte("d5:peersld4:porti1e2:ip1:aeee")
+	var resp TrackerResp
+	err := bencode.Unmarshal(data, &resp)
+	if err != nil || len(resp.Peers) != 1 || resp.Peers[0].IP != "a" {
+		t.Fatalf("got %v, %v", resp.Peers, err)
+	}
+	err = bencode.DecodeOptions{Strict: true}.Unmarshal(data, &resp)
+	if !errors.Is(err, bencode.ErrUnsorted) {
+		t.Fatalf("got %v, want ErrUnsorted", err)
+	}
+
+	data = []byte("d5:peersld2:ip1:a4:porti1eed2:ip1:b4:porti2eeee")
+	err = bencode.DecodeOptions{Limits: &bencode.Limits{MaxElements: 7}}.Unmarshal(data, &resp)
+	var le *bencode.LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxElements" {
+		t.Fatalf("got %v, want MaxElements", err)
+	}
+	err = bencode.DecodeOptions{Limits: &bencode.Limits{MaxElements: 8}}.Unmarshal(data, &resp)
+	if err != nil || len(resp.Peers) != 2 {
+		t.Fatalf("got %v, %v", resp.Peers, err)
+	}
+
+	// 紧凑格式同样检查字符串长度的限制
+	err = bencode.DecodeOptions{Limits: &bencode.Limits{MaxStringLen: 5}}.Unmarshal([]byte("d5:peers6:\x7f\x00\x00\x01\x1a\xe1e"), &resp)
+	if !errors.As(err, &le) || le.Limit != "MaxStringLen" {
+		t.Fatalf("got %v, want MaxStringLen", err)
+	}
+}